@@ -31,7 +31,7 @@ SOFTWARE.
 package main
 
 import (
-	"os/user"
+	"context"
 	"strings"
 
 	gogenda "github.com/lethenju/gogenda/internal"
@@ -39,6 +39,9 @@ import (
 	"github.com/lethenju/gogenda/internal/configuration"
 	"github.com/lethenju/gogenda/internal/current_activity"
 	"github.com/lethenju/gogenda/internal/gogendalib"
+	"github.com/lethenju/gogenda/internal/profiles"
+	"github.com/lethenju/gogenda/internal/templates"
+	"github.com/lethenju/gogenda/internal/utilities"
 	"github.com/lethenju/gogenda/pkg/colors"
 	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
 )
@@ -48,23 +51,31 @@ const version = "0.3.0"
 
 // Main entry point
 func main() {
-	usr, _ := user.Current()
-	userDir := usr.HomeDir
-
 	args := cmdOptions.Init()
 	// Setup colors printing
 	colors.SetupColors()
-	// Connect to API
-	srv, err := api.Connect()
-
+	// Each profile has its own credentials, token, configuration, templates and state
+	profile, err := cmdOptions.GetStringOption("profile")
+	if err != nil {
+		profile = profiles.GetCurrent()
+	}
+	dir, err := profiles.Dir(profile)
 	if err != nil {
 		colors.DisplayError(err.Error())
 		return
 	}
+	if !profiles.Exists(profile) {
+		colors.DisplayError("Profile '" + profile + "' does not exist, add it with 'gogenda profile add " + profile + "'")
+		return
+	}
+	api.Dir = dir
+	templates.Dir = dir
+	utilities.Dir = dir
+
 	config, err := cmdOptions.GetStringOption("config")
 	if err != nil {
 		// Load default configuration
-		config = userDir + "/.gogenda/config.json"
+		config = dir + "/config.json"
 	}
 	// Load user defined config (absolute path)
 	err = configuration.LoadConfiguration(config)
@@ -72,6 +83,44 @@ func main() {
 		// Conf doesnt exist
 		colors.DisplayError("Could not open " + config)
 	}
+	utilities.WeekStart = configuration.GetWeekStart()
+	if timeout := configuration.GetTimeout(); timeout > 0 {
+		api.Timeout = timeout
+	}
+	utilities.Clock12h = configuration.IsClock12h()
+	utilities.DateFormat, err = configuration.GetDateFormat()
+	if err != nil {
+		colors.DisplayError(err.Error())
+	}
+	location, err := configuration.GetTimezone()
+	if err != nil {
+		colors.DisplayError("Wrong timezone in " + config + " : " + err.Error())
+	} else {
+		utilities.TimeZone = location
+	}
+	api.TokenStorage, err = configuration.GetTokenStorage()
+	if err != nil {
+		colors.DisplayError(err.Error())
+	}
+	api.AskPassphrase = utilities.InputSecretFromUser
+	api.ManualAuth = cmdOptions.IsOptionSet("no-browser")
+	api.CacheEnabled = !cmdOptions.IsOptionSet("no-cache")
+
+	if len(args) > 0 && !cmdOptions.IsOptionSet("help") &&
+		(strings.ToUpper(args[0]) == "PROFILE" || strings.ToUpper(args[0]) == "AUTH") {
+		// The profiles and the authorization are managed without connecting to the calendar first
+		err = gogendalib.CommandHandler(args, nil, false)
+		if err != nil {
+			colors.DisplayError("ERROR : " + err.Error())
+		}
+		return
+	}
+	// Connect to API
+	srv, err := api.Connect(context.Background())
+	if err != nil {
+		colors.DisplayError(err.Error())
+		return
+	}
 	if cmdOptions.IsOptionSet("help") {
 		if len(args) > 0 {
 			gogendalib.CommandHandler([]string{"HELP", args[0]}, srv, false)
@@ -85,7 +134,7 @@ func main() {
 
 		// For the other commands than start its obvious he/she is
 		if strings.ToUpper(args[0]) != "START" {
-			currentActivity, _ := api.GetLastEvent(srv)
+			currentActivity, _ := api.GetLastEvent(context.Background(), srv)
 			current_activity.SetCurrentActivity(&currentActivity)
 		}
 		err = gogendalib.CommandHandler(args, srv, false)
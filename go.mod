@@ -5,6 +5,7 @@ go 1.13
 require (
 	github.com/fatih/color v1.9.0
 	github.com/go-echarts/go-echarts/v2 v2.2.4 // indirect
+	golang.org/x/crypto v0.0.0-20191011191535-87dc89f01550
 	golang.org/x/net v0.0.0-20200506145744-7e3656a0809f
 	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d
 	google.golang.org/api v0.24.0
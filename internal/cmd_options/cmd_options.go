@@ -32,6 +32,7 @@ package cmdOptions
 import (
 	"errors"
 	"flag"
+	"strings"
 )
 
 var setOptions map[string]string
@@ -44,6 +45,9 @@ func Init() []string {
 	help := flag.Bool("h", false, "Help")
 	compact := flag.Bool("compact", false, "Compact output")
 	config := flag.String("config", "", "Custom configuration")
+	noCache := flag.Bool("no-cache", false, "Ask the events to the api instead of the local cache")
+	profile := flag.String("profile", "", "Use another profile than the default one")
+	noBrowser := flag.Bool("no-browser", false, "Log in from another machine, pasting back the address the browser was sent to")
 
 	flag.Parse()
 
@@ -59,6 +63,15 @@ func Init() []string {
 	if *config != "" {
 		setOptions["config"] = *config
 	}
+	if *noCache {
+		setOptions["no-cache"] = "true"
+	}
+	if *profile != "" {
+		setOptions["profile"] = *profile
+	}
+	if *noBrowser {
+		setOptions["no-browser"] = "true"
+	}
 	return flag.Args()
 }
 
@@ -80,3 +93,66 @@ func GetStringOption(option string) (string, error) {
 func GetNumberOfOptions() int {
 	return len(setOptions)
 }
+
+// ExtractCommandOptions extracts the options given after a command name, like "--min 45m", "--min=45m" or "--json".
+// valueOptions are the options that take a value, boolOptions the ones that dont.
+// Returns the command without its options, and the value of each option set ("true" for boolOptions)
+func ExtractCommandOptions(command []string, valueOptions []string, boolOptions []string) (rest []string, options map[string]string, err error) {
+	options = make(map[string]string)
+	isIn := func(name string, list []string) bool {
+		for _, option := range list {
+			if option == name {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; i < len(command); i++ {
+		if !strings.HasPrefix(command[i], "--") {
+			rest = append(rest, command[i])
+			continue
+		}
+		name := strings.TrimPrefix(command[i], "--")
+		value := ""
+		hasValue := false
+		if equal := strings.Index(name, "="); equal >= 0 {
+			name, value, hasValue = name[:equal], name[equal+1:], true
+		}
+		switch {
+		case isIn(name, boolOptions) && !hasValue:
+			options[name] = "true"
+		case isIn(name, valueOptions):
+			if !hasValue {
+				if i+1 >= len(command) {
+					return rest, options, errors.New("Missing value for option --" + name)
+				}
+				i++
+				value = command[i]
+			}
+			options[name] = value
+		default:
+			return rest, options, errors.New("Unknown option --" + name)
+		}
+	}
+	return rest, options, nil
+}
+
+// ExtractCommandOption extracts one option taking a value from a command, like "--tz Europe/Paris" or "--tz=UTC",
+// and leaves the other options in place. Returns the command without that option, and its value if it was set
+func ExtractCommandOption(command []string, name string) (rest []string, value string, err error) {
+	for i := 0; i < len(command); i++ {
+		switch {
+		case command[i] == "--"+name:
+			if i+1 >= len(command) {
+				return rest, value, errors.New("Missing value for option --" + name)
+			}
+			i++
+			value = command[i]
+		case strings.HasPrefix(command[i], "--"+name+"="):
+			value = strings.TrimPrefix(command[i], "--"+name+"=")
+		default:
+			rest = append(rest, command[i])
+		}
+	}
+	return rest, value, nil
+}
@@ -34,6 +34,7 @@ import (
 	"errors"
 	"os"
 	"strings"
+	"time"
 )
 
 // ConfigCategory is a category of activity
@@ -45,10 +46,41 @@ type ConfigCategory struct {
 	Color string `json:"color"`
 }
 
+// ConfigWorkingHours are the hours where activities are expected to be logged
+type ConfigWorkingHours struct {
+
+	// Start time, "HH:MM"
+	Start string `json:"start"`
+	// End time, "HH:MM"
+	End string `json:"end"`
+	// Days of the week, like "mon"
+	Days []string `json:"days"`
+}
+
 // Config represents the configuration of the app
 type Config struct {
 	// Categories are the active categories of activities
 	Categories []ConfigCategory `json:"categories"`
+	// WorkingHours are the hours where activities are expected to be logged
+	WorkingHours ConfigWorkingHours `json:"workingHours"`
+	// MaxBlock is the maximum duration of a block planned by the scheduler, like "2h"
+	MaxBlock string `json:"maxBlock"`
+	// WeekStart is the first day of the weeks, like "mon" or "sun"
+	WeekStart string `json:"weekStart"`
+	// Timezone is the timezone the dates are given and shown in, like "Europe/Paris"
+	Timezone string `json:"timezone"`
+	// Locale gives the default date format and clock, like "en-US" or "fr-FR"
+	Locale string `json:"locale"`
+	// DateFormat is the order the dates are written and shown in : "YYYY-MM-DD", "MM/DD" or "DD/MM"
+	DateFormat string `json:"dateFormat"`
+	// Clock is "24h" or "12h"
+	Clock string `json:"clock"`
+	// AllDayDuration is the time an all day event counts for in the stats, like "8h". They are left out without it
+	AllDayDuration string `json:"allDayDuration"`
+	// Timeout is the time each request to the calendar is given before being retried, like "10s"
+	Timeout string `json:"timeout"`
+	// TokenStorage is where the token of the calendar is kept : "file" (by default), "passphrase" or "keyring"
+	TokenStorage string `json:"tokenStorage"`
 }
 
 // Conf is the globally accessible configuration
@@ -96,3 +128,103 @@ func GetNameFromColor(color string) (name string) {
 	}
 	return ourCategory.Name
 }
+
+//GetWorkingHours returns the working hours, "09:00" to "18:00" from monday to friday if they are not configured
+func GetWorkingHours() ConfigWorkingHours {
+	workingHours := conf.WorkingHours
+	if workingHours.Start == "" {
+		workingHours.Start = "09:00"
+	}
+	if workingHours.End == "" {
+		workingHours.End = "18:00"
+	}
+	if len(workingHours.Days) == 0 {
+		workingHours.Days = []string{"mon", "tue", "wed", "thu", "fri"}
+	}
+	return workingHours
+}
+
+//GetMaxBlock returns the maximum duration of a block planned by the scheduler, 2 hours if it is not configured
+func GetMaxBlock() time.Duration {
+	maxBlock, err := time.ParseDuration(conf.MaxBlock)
+	if err != nil || maxBlock <= 0 {
+		return 2 * time.Hour
+	}
+	return maxBlock
+}
+
+//GetWeekStart returns the first day of the weeks, monday if it is not configured
+func GetWeekStart() time.Weekday {
+	days := []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+	for i, day := range days {
+		if strings.HasPrefix(strings.ToLower(conf.WeekStart), day) {
+			return time.Weekday(i)
+		}
+	}
+	return time.Monday
+}
+
+//GetTimezone returns the timezone the dates are given and shown in, the one of the system if it is not configured
+func GetTimezone() (*time.Location, error) {
+	if conf.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(conf.Timezone)
+}
+
+//GetDateFormat returns the order the dates are written and shown in : "YYYY-MM-DD", "MM/DD" or "DD/MM".
+//If it is not configured, it is "MM/DD" for the "en-US" locale, "DD/MM" for the other locales, and "YYYY-MM-DD" without locale
+func GetDateFormat() (string, error) {
+	switch strings.ToUpper(conf.DateFormat) {
+	case "YYYY-MM-DD", "MM/DD", "DD/MM":
+		return strings.ToUpper(conf.DateFormat), nil
+	case "":
+		switch {
+		case conf.Locale == "":
+			return "YYYY-MM-DD", nil
+		case strings.HasSuffix(strings.ToUpper(conf.Locale), "US"):
+			return "MM/DD", nil
+		}
+		return "DD/MM", nil
+	}
+	return "YYYY-MM-DD", errors.New("Wrong date format '" + conf.DateFormat + "', should be YYYY-MM-DD, MM/DD or DD/MM")
+}
+
+//IsClock12h returns true when the times are shown with am/pm. If it is not configured, only the "en-US" locale uses it
+func IsClock12h() bool {
+	if conf.Clock == "" {
+		return strings.HasSuffix(strings.ToUpper(conf.Locale), "US")
+	}
+	return strings.ToLower(conf.Clock) == "12h"
+}
+
+//GetAllDayDuration returns the time each day of an all day event counts for in the stats, 0 if it is not configured
+func GetAllDayDuration() time.Duration {
+	duration, err := time.ParseDuration(conf.AllDayDuration)
+	if err != nil || duration < 0 {
+		return 0
+	}
+	return duration
+}
+
+//GetTimeout returns the time each request to the calendar is given, 0 if it is not configured
+func GetTimeout() time.Duration {
+	duration, err := time.ParseDuration(conf.Timeout)
+	if err != nil || duration <= 0 {
+		return 0
+	}
+	return duration
+}
+
+//GetTokenStorage returns where the token of the calendar is kept, "file" if it is not configured
+func GetTokenStorage() (string, error) {
+	switch strings.ToLower(conf.TokenStorage) {
+	case "", "file":
+		return "file", nil
+	case "passphrase":
+		return "passphrase", nil
+	case "keyring":
+		return "keyring", nil
+	}
+	return "file", errors.New("Wrong token storage '" + conf.TokenStorage + "', should be file, passphrase or keyring")
+}
@@ -0,0 +1,85 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/lethenju/gogenda/internal/utilities"
+	"github.com/lethenju/gogenda/pkg/colors"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
+)
+
+// authCommand logs in, shows the authorization of gogenda on the calendar, or logs out
+func authCommand(ctx context.Context, command Command) (err error) {
+	action := "STATUS"
+	if len(command) > 1 {
+		action = strings.ToUpper(command[1])
+	}
+	switch action {
+	case "LOGIN":
+		err = api.Login(ctx)
+		if err != nil {
+			return err
+		}
+		colors.DisplayOk("Logged in !")
+	case "STATUS":
+		status, err := api.GetAuthStatus(ctx)
+		if err != nil {
+			return err
+		}
+		colors.DisplayInfoHeading(" Authorization ")
+		colors.DisplayOk(" Account : " + status.Account)
+		colors.DisplayOk(" Scopes : " + strings.Join(status.Scopes, ", "))
+		colors.DisplayOk(" Access token expires : " + utilities.FormatDateTime(status.Expiry.In(utilities.Location(ctx))))
+		if status.CanRefresh {
+			colors.DisplayOk(" It is refreshed automatically")
+		} else {
+			colors.DisplayError(" It cannot be refreshed, log in again with 'auth login' once it expires")
+		}
+		colors.DisplayOk(" Token kept in : " + status.Storage)
+	case "LOGOUT":
+		isOkay := utilities.AskOkFromUser("Revoke the access of gogenda to your calendar and delete its token ?")
+		if !isOkay {
+			colors.DisplayInfo("Aborting..")
+			return nil
+		}
+		err = api.Logout(ctx)
+		if err != nil {
+			return err
+		}
+		colors.DisplayOk("Logged out, gogenda cannot access your calendar anymore")
+	default:
+		return errors.New("Wrong argument '" + command[1] + "', should be login, status or logout")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"context"
+	"time"
+
+	"github.com/lethenju/gogenda/internal/utilities"
+	"github.com/lethenju/gogenda/pkg/colors"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
+	"google.golang.org/api/calendar/v3"
+)
+
+// describeBatchItem gives a one line description of a mutation of a batch, in the time zone given in parameters
+func describeBatchItem(item api.BatchItem, location *time.Location) string {
+	switch item.Operation {
+	case api.BatchInsert:
+		return "add " + describeEvent(item.Event, location)
+	case api.BatchPatch:
+		return "update " + describeEvent(item.Loaded, location)
+	default:
+		return "delete " + describeEvent(item.Loaded, location)
+	}
+}
+
+// sendBatch sends the mutations of a batch, shows the ones that failed and lets the user resolve the conflicts.
+// Returns the number of mutations that failed, and for each item whether it has been done
+func sendBatch(ctx context.Context, items []api.BatchItem, srv *calendar.Service) (nbErrors int, done []bool) {
+	done = make([]bool, len(items))
+	for i, result := range api.RunBatch(ctx, items, srv) {
+		err := ResolveEditConflict(ctx, result.Err, srv)
+		if err != nil {
+			nbErrors++
+			colors.DisplayError(" Could not " + describeBatchItem(result.Item, utilities.Location(ctx)) + " : " + err.Error())
+			continue
+		}
+		done[i] = true
+	}
+	return nbErrors, done
+}
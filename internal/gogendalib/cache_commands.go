@@ -0,0 +1,74 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/lethenju/gogenda/internal/utilities"
+	"github.com/lethenju/gogenda/pkg/colors"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
+)
+
+// cacheCommand shows or clears the local cache of the events
+func cacheCommand(command Command) (err error) {
+	action := "STATUS"
+	if len(command) > 1 {
+		action = strings.ToUpper(command[1])
+	}
+	switch action {
+	case "STATUS":
+		status, err := api.GetCacheStatus()
+		if err != nil {
+			return err
+		}
+		if status.LastSync.IsZero() {
+			colors.DisplayOk("The cache is empty, it will be filled by the next command reading your calendar")
+			return nil
+		}
+		colors.DisplayInfoHeading(" Cache of your events ")
+		colors.DisplayOk(" File : " + status.Path + " (" + strconv.FormatInt(status.Size/1024, 10) + " KB)")
+		colors.DisplayOk(" Events : " + strconv.Itoa(status.NbEvents))
+		colors.DisplayOk(" Dates kept : " + utilities.FormatDate(status.WindowBegin) + " to " + utilities.FormatDate(status.WindowEnd) +
+			", the others are asked to google agenda")
+		colors.DisplayOk(" Last changes synced : " + utilities.FormatDateTime(status.LastSync))
+	case "CLEAR":
+		err = api.ClearCache()
+		if err != nil {
+			return err
+		}
+		colors.DisplayOk("Cache cleared")
+	default:
+		return errors.New("Wrong argument '" + command[1] + "', should be status or clear")
+	}
+	return nil
+}
@@ -30,62 +30,193 @@ SOFTWARE.
 package gogendalib
 
 import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
 	"strings"
+	"time"
 
+	cmdOptions "github.com/lethenju/gogenda/internal/cmd_options"
+	"github.com/lethenju/gogenda/internal/utilities"
 	"github.com/lethenju/gogenda/pkg/colors"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
 	"google.golang.org/api/calendar/v3"
 )
 
 // CommandHandler takes the command in parameter and dispatchs it to the different command methods in command.go
 func CommandHandler(command []string, srv *calendar.Service, isShell bool) (err error) {
+	defer func() {
+		// Aborting is not an error, and it has already been shown
+		if err == ErrAborted {
+			err = nil
+		}
+	}()
+
+	// Any command can be run in another timezone than the configured one
+	command, timezone, err := cmdOptions.ExtractCommandOption(command, "tz")
+	if err != nil {
+		return err
+	}
+	location := utilities.TimeZone
+	if timezone != "" {
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return errors.New("Wrong argument '" + timezone + "', should be a timezone like Europe/Paris")
+		}
+	}
+
+	// Dates and times can be written in several words, like "next fri" or "in 2 days"
+	command = append([]string{command[0]}, utilities.MergeDateExpressions(command[1:])...)
+
+	// The requests of the command are canceled with ctrl+c
+	// The dates of the command are read and shown in its time zone
+	ctx, cancel := context.WithCancel(utilities.WithLocation(context.Background(), location))
+	defer cancel()
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	go func() {
+		select {
+		case <-interrupts:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// The changes done offline are sent as soon as the calendar can be reached again
+	name := strings.ToUpper(command[0])
+	if name != "SYNC" && name != "HELP" && name != "PROFILE" && name != "AUTH" && api.CountPendingMutations() > 0 {
+		errSync := sendPendingMutations(ctx, srv)
+		if errSync != nil {
+			colors.DisplayError(errSync.Error())
+		}
+	}
+	defer func(pendingBefore int) {
+		if api.CountPendingMutations() > pendingBefore {
+			colors.DisplayInfo("Offline : the change will be sent by the next command, or with 'gogenda sync'")
+		}
+	}(api.CountPendingMutations())
 
 	// Our command name is in the first argument
-	switch strings.ToUpper(command[0]) {
+	switch name {
 	// Start an event
 	case "START":
-		err = startCommand(command, srv)
+		err = startCommand(ctx, command, srv)
 		if err != nil {
 			return err
 		}
 	case "STOP":
 		// Stop an event
-		err = stopCommand(srv)
+		err = stopCommand(ctx, srv)
 		if err != nil {
 			return err
 		}
 	case "RENAME":
 		// Renames an event
-		err = renameCommand(command, srv)
+		err = renameCommand(ctx, command, srv)
 		if err != nil {
 			return err
 		}
 	case "DELETE":
 		// Deletes an event
-		err = deleteCommand(srv)
+		err = deleteCommand(ctx, srv)
 		if err != nil {
 			return err
 		}
 	case "PLAN":
 		// Show the plan of the date (or today if no date)
-		err = planCommand(command, srv)
+		err = planCommand(ctx, command, srv)
 		if err != nil {
 			return err
 		}
 	case "ADD":
 		// add an event to the calendar at a specific date
-		err = addCommand(command, srv)
+		err = addCommand(ctx, command, srv)
 		if err != nil {
 			return err
 		}
 	case "STATS":
 		// add an event to the calendar at a specific date
-		err = statsCommand(command, srv)
+		err = statsCommand(ctx, command, srv)
 		if err != nil {
 			return err
 		}
 	case "GRAPH":
 		// graph command
-		err = GraphCommand(command, srv)
+		err = GraphCommand(ctx, command, srv)
+		if err != nil {
+			return err
+		}
+	case "TEMPLATE":
+		// Save days as templates and apply them to other dates
+		err = templateCommand(ctx, command, srv)
+		if err != nil {
+			return err
+		}
+	case "LINT":
+		// Find the problems of the logged history
+		err = lintCommand(ctx, command, srv)
+		if err != nil {
+			return err
+		}
+	case "FILL":
+		// Fill the untracked gaps of a day
+		err = fillCommand(ctx, command, srv)
+		if err != nil {
+			return err
+		}
+	case "FREE":
+		// Find the free slots of time
+		err = freeCommand(ctx, command, srv)
+		if err != nil {
+			return err
+		}
+	case "SCHEDULE":
+		// Place tasks in the free time
+		err = scheduleCommand(ctx, command, srv)
+		if err != nil {
+			return err
+		}
+	case "REVIEW":
+		// Compare what was planned to what was done
+		err = reviewCommand(ctx, command, srv)
+		if err != nil {
+			return err
+		}
+	case "SYNC":
+		// Send the changes done offline
+		err = syncCommand(ctx, srv)
+		if err != nil {
+			return err
+		}
+	case "CACHE":
+		// Show or clear the local cache of the events
+		err = cacheCommand(command)
+		if err != nil {
+			return err
+		}
+	case "PROFILE":
+		// Manage the profiles, each with its own account and configuration
+		err = profileCommand(command)
+		if err != nil {
+			return err
+		}
+	case "AUTH":
+		// Log in, show the authorization or log out
+		err = authCommand(ctx, command)
+		if err != nil {
+			return err
+		}
+	case "HISTORY":
+		// Show the last mutations done on the calendar
+		err = historyCommand(ctx, command)
+		if err != nil {
+			return err
+		}
+	case "UNDO":
+		// Revert the last mutations done on the calendar
+		err = undoCommand(ctx, command, srv)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,94 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/lethenju/gogenda/internal/utilities"
+	"github.com/lethenju/gogenda/pkg/colors"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
+	"google.golang.org/api/calendar/v3"
+)
+
+// ErrAborted is returned when the user aborted the resolution of a conflict : the change has not been done.
+// The command handler does not show it as an error, the user already knows
+var ErrAborted = errors.New("aborted")
+
+// ResolveEditConflict handles the error of a change done on an event. If the event had been modified
+// on the calendar meanwhile, it shows what changed and lets the user overwrite, merge or abort (ErrAborted).
+// Other errors are returned as they are.
+func ResolveEditConflict(ctx context.Context, err error, srv *calendar.Service) error {
+	return resolveEventConflict(ctx, nil, err, srv)
+}
+
+// resolveEventConflict handles the error of a change done on the event given in parameters, like ResolveEditConflict.
+// Once the conflict is resolved, the event is updated in place with its state on the calendar, if it is not nil
+func resolveEventConflict(ctx context.Context, event *calendar.Event, err error, srv *calendar.Service) error {
+	conflict, isConflict := err.(*api.ConflictError)
+	if !isConflict {
+		return err
+	}
+	overwrite, ok := askConflictResolution(conflict)
+	if !ok {
+		return ErrAborted
+	}
+	after, err := api.ResolveConflict(ctx, conflict, overwrite, srv)
+	if err != nil {
+		return err
+	}
+	if event != nil {
+		*event = *after
+	}
+	return nil
+}
+
+// askConflictResolution shows what changed on the calendar and asks the user to overwrite, merge or abort.
+// Returns false if the user aborted
+func askConflictResolution(conflict *api.ConflictError) (overwrite bool, ok bool) {
+	colors.DisplayError("The event '" + conflict.Loaded.Summary + "' has been modified on the calendar meanwhile :")
+	for _, change := range conflict.Changes() {
+		colors.DisplayInfo(" " + change)
+	}
+	for {
+		answer := strings.ToLower(utilities.InputFromUser("(o)verwrite their changes, (m)erge with them or (a)bort"))
+		switch answer {
+		case "o", "overwrite":
+			return true, true
+		case "m", "merge":
+			return false, true
+		case "a", "abort":
+			colors.DisplayInfo("Aborting..")
+			return false, false
+		}
+	}
+}
@@ -0,0 +1,288 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lethenju/gogenda/internal/configuration"
+	"github.com/lethenju/gogenda/internal/utilities"
+	"github.com/lethenju/gogenda/pkg/colors"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
+	"google.golang.org/api/calendar/v3"
+)
+
+// dayLine is an event of the day as it is written in the edit-day buffer
+type dayLine struct {
+	// index of the original event in the day, -1 if the line is a new event
+	index    int
+	begin    time.Time
+	end      time.Time
+	category string
+	name     string
+}
+
+// String formats the line the way it is written in the buffer
+func (line dayLine) String() string {
+	str := utilities.FormatTime(line.begin) + " " + utilities.FormatTime(line.end) + " " + line.category + " " + line.name
+	if line.index >= 0 {
+		str += " #" + strconv.Itoa(line.index)
+	}
+	return str
+}
+
+// equals checks if two lines describe the same event
+func (line dayLine) equals(other dayLine) bool {
+	return line.begin.Equal(other.begin) && line.end.Equal(other.end) &&
+		strings.ToUpper(line.category) == strings.ToUpper(other.category) && line.name == other.name
+}
+
+// lineFromEvent builds the buffer line of an event of the day, in the time zone given in parameters
+func lineFromEvent(index int, event *calendar.Event, location *time.Location) dayLine {
+	beginTime, _ := api.GetEventStart(event, location)
+	endTime, _ := api.GetEventEnd(event, location)
+	color, _ := api.GetColorNameFromColorID(event.ColorId)
+	category := configuration.GetNameFromColor(color)
+	if category == "default" {
+		category = "-"
+	}
+	return dayLine{index: index, begin: beginTime, end: endTime, category: category, name: event.Summary}
+}
+
+// parseDayLine parses a line of the buffer written by the user
+// format is "start end CATEGORY summary" with an optional "#id" at the end for existing events
+func parseDayLine(str string, day time.Time) (line dayLine, err error) {
+	line.index = -1
+	fields := strings.Fields(str)
+	if len(fields) > 3 && strings.HasPrefix(fields[len(fields)-1], "#") {
+		line.index, err = strconv.Atoi(fields[len(fields)-1][1:])
+		if err != nil {
+			return line, errors.New("wrong id '" + fields[len(fields)-1] + "'")
+		}
+		fields = fields[:len(fields)-1]
+	}
+	if len(fields) < 4 {
+		return line, errors.New("a line should be 'start end CATEGORY summary'")
+	}
+	t, err := utilities.TimeParser(fields[0], day.Location())
+	if err != nil {
+		return line, errors.New("wrong start time '" + fields[0] + "'")
+	}
+	line.begin = time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), t.Second(), 0, day.Location())
+	t, err = utilities.TimeParser(fields[1], day.Location())
+	if err != nil {
+		return line, errors.New("wrong end time '" + fields[1] + "'")
+	}
+	line.end = time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), t.Second(), 0, day.Location())
+	if !line.end.After(line.begin) {
+		// like if the user wanted an event between 2 days (23:00 -> 01:00)
+		line.end = line.end.AddDate(0, 0, 1)
+	}
+	line.category = fields[2]
+	line.name = strings.Join(fields[3:], " ")
+	return line, nil
+}
+
+// editInEditor opens the content in the editor of the user ($EDITOR, or vi) and returns the edited content
+func editInEditor(content string) (string, error) {
+	f, err := ioutil.TempFile("", "gogenda-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(content)
+	f.Close()
+	if err != nil {
+		return "", err
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	// $EDITOR can have arguments, like "code -w"
+	editorArgs := strings.Fields(editor)
+	cmd := exec.Command(editorArgs[0], append(editorArgs[1:], f.Name())...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if err != nil {
+		return "", errors.New("editor '" + editor + "' failed : " + err.Error())
+	}
+	b, err := ioutil.ReadFile(f.Name())
+	return string(b), err
+}
+
+// planEditDayCommand dumps the events of a day in the editor of the user, and applies
+// the inserts, updates and deletes the user did in the buffer, like a 'git rebase -i'
+func planEditDayCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
+	location := utilities.Location(ctx)
+	day := time.Now().In(location)
+	if len(command) > 1 {
+		day, err = utilities.DateParser(command[1], location)
+		if err != nil {
+			return err
+		}
+	}
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, location)
+	end := day.AddDate(0, 0, 1)
+
+	cals, err := api.GetActivitiesBetweenDates(ctx, day.Format(time.RFC3339), end.Format(time.RFC3339), srv)
+	if err != nil {
+		return err
+	}
+	events := cals.Items
+
+	// Build the buffer
+	var originalLines []dayLine
+	var buffer strings.Builder
+	for i, event := range events {
+		if event.Start.DateTime == "" {
+			// All day events cannot be edited that way
+			continue
+		}
+		line := lineFromEvent(i, event, location)
+		originalLines = append(originalLines, line)
+		buffer.WriteString(line.String() + "\n")
+	}
+	buffer.WriteString("\n")
+	buffer.WriteString("# Edit the events of " + utilities.FormatDate(day) + "\n")
+	buffer.WriteString("#\n")
+	buffer.WriteString("# One event per line : start end CATEGORY summary #id\n")
+	buffer.WriteString("# - change the times, the category or the summary to update an event\n")
+	buffer.WriteString("# - remove a line to delete its event\n")
+	buffer.WriteString("# - add a line without #id to add an event (use '-' for no category)\n")
+	buffer.WriteString("# - lines can be reordered, the times are what matters\n")
+	buffer.WriteString("#\n")
+	buffer.WriteString("# Lines starting with '#' are ignored. Empty the file to abort.\n")
+
+	edited, err := editInEditor(buffer.String())
+	if err != nil {
+		return err
+	}
+
+	// Parse the edited buffer
+	var editedLines []dayLine
+	isEmpty := true
+	scanner := bufio.NewScanner(strings.NewReader(edited))
+	for nb := 1; scanner.Scan(); nb++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		isEmpty = false
+		line, err := parseDayLine(text, day)
+		if err != nil {
+			return errors.New("line " + strconv.Itoa(nb) + " : " + err.Error())
+		}
+		editedLines = append(editedLines, line)
+	}
+	if isEmpty {
+		colors.DisplayInfo("Empty buffer, aborting..")
+		return nil
+	}
+
+	// Compute the inserts, updates and deletes
+	originals := make(map[int]dayLine)
+	for _, line := range originalLines {
+		originals[line.index] = line
+	}
+	seen := make(map[int]bool)
+	var inserts, updates, deletes []dayLine
+	for _, line := range editedLines {
+		original, exists := originals[line.index]
+		if !exists || seen[line.index] {
+			// New line, or a duplicated one : it is a new event
+			line.index = -1
+			inserts = append(inserts, line)
+			continue
+		}
+		seen[line.index] = true
+		if !line.equals(original) {
+			updates = append(updates, line)
+		}
+	}
+	for _, line := range originalLines {
+		if !seen[line.index] {
+			deletes = append(deletes, line)
+		}
+	}
+
+	if len(inserts)+len(updates)+len(deletes) == 0 {
+		colors.DisplayOk("Nothing to do")
+		return nil
+	}
+	for _, line := range inserts {
+		colors.DisplayOk(" + " + line.String())
+	}
+	for _, line := range updates {
+		colors.DisplayOk(" ~ " + originals[line.index].String() + "  =>  " + line.String())
+	}
+	for _, line := range deletes {
+		colors.DisplayOk(" - " + line.String())
+	}
+	isOkay := utilities.AskOkFromUser("Are you okay with those " + strconv.Itoa(len(inserts)+len(updates)+len(deletes)) + " operations ?")
+	if !isOkay {
+		colors.DisplayInfo("Aborting..")
+		return nil
+	}
+
+	// Apply them
+	var items []api.BatchItem
+	for _, line := range deletes {
+		items = append(items, api.DeleteItem(events[line.index]))
+	}
+	for _, line := range updates {
+		items = append(items, api.UpdateItem(events[line.index], line.name, configuration.GetColorFromName(line.category), line.begin, line.end))
+	}
+	for _, line := range inserts {
+		if line.begin.After(time.Now()) {
+			items = append(items, api.InsertPlannedItem(line.name, configuration.GetColorFromName(line.category), line.begin, line.end))
+		} else {
+			items = append(items, api.InsertLoggedItem(line.name, configuration.GetColorFromName(line.category), line.begin, line.end))
+		}
+	}
+	nbErrors, _ := sendBatch(ctx, items, srv)
+	if nbErrors > 0 {
+		return fmt.Errorf("%d operations failed", nbErrors)
+	}
+	colors.DisplayOk("Successfully edited the day !")
+	return nil
+}
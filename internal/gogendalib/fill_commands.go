@@ -0,0 +1,182 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lethenju/gogenda/internal/configuration"
+	"github.com/lethenju/gogenda/internal/utilities"
+	"github.com/lethenju/gogenda/pkg/colors"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
+	"google.golang.org/api/calendar/v3"
+)
+
+// Gaps shorter than that are not worth filling
+const fillMinGap = 5 * time.Minute
+
+// fillEntry is an event to add in a gap
+type fillEntry struct {
+	slot     interval
+	category string
+	name     string
+}
+
+// eventCategory returns the category of an event, empty if it has none
+func eventCategory(event *calendar.Event) string {
+	color, _ := api.GetColorNameFromColorID(event.ColorId)
+	category := configuration.GetNameFromColor(color)
+	if category == "default" {
+		return ""
+	}
+	return category
+}
+
+// neighbourEvents returns the last event ending before the gap and the first event starting after it, if any
+func neighbourEvents(gap interval, events []*calendar.Event) (previous *calendar.Event, next *calendar.Event) {
+	for _, event := range events {
+		slot, ok := eventInterval(event, gap.begin.Location())
+		if !ok {
+			continue
+		}
+		if !slot.end.After(gap.begin) {
+			previous = event
+		}
+		if !slot.begin.Before(gap.end) && next == nil {
+			next = event
+		}
+	}
+	return previous, next
+}
+
+// describeTask describes the task of an event, as proposed to fill a gap
+func describeTask(event *calendar.Event) string {
+	return "'" + event.Summary + "' [" + eventCategory(event) + "]"
+}
+
+// fillCommand walks the untracked gaps of the day in the working hours, and asks for each one what was done
+func fillCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
+	location := utilities.Location(ctx)
+	now := time.Now().In(location)
+	day := now
+	if len(command) > 1 {
+		day, err = utilities.DateParser(command[1], location)
+		if err != nil {
+			return err
+		}
+	}
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, location)
+	workingHours := configuration.GetWorkingHours()
+	slot, ok := hoursInterval(day, workingHours.Start, workingHours.End)
+	if !ok {
+		return errorWrongWorkingHours
+	}
+	if slot.end.After(now) {
+		slot.end = now
+	}
+	if !slot.end.After(slot.begin) {
+		colors.DisplayOk("The working hours of that day did not start yet")
+		return nil
+	}
+
+	cals, err := api.GetActivitiesBetweenDates(ctx, day.Format(time.RFC3339), day.AddDate(0, 0, 1).Format(time.RFC3339), srv)
+	if err != nil {
+		return err
+	}
+	gaps := findGaps(slot, cals.Items, fillMinGap)
+	if len(gaps) == 0 {
+		colors.DisplayOk("No gaps to fill, well done !")
+		return nil
+	}
+
+	var entries []fillEntry
+	for _, gap := range gaps {
+		colors.DisplayInfoHeading(" [ " + utilities.FormatTime(gap.begin) + " -> " + utilities.FormatTime(gap.end) + " ] " + gap.duration().Truncate(time.Minute).String() + " untracked")
+		previous, next := neighbourEvents(gap, cals.Items)
+		question := ""
+		if previous != nil {
+			question += "(p)revious " + describeTask(previous) + ", "
+		}
+		if next != nil {
+			question += "(n)ext " + describeTask(next) + ", "
+		}
+		question += "(s)kip, or type 'CATEGORY name'"
+		for {
+			answer := strings.TrimSpace(utilities.InputFromUser(question))
+			entry := fillEntry{slot: gap}
+			switch {
+			case strings.ToLower(answer) == "p" && previous != nil:
+				entry.category, entry.name = eventCategory(previous), previous.Summary
+			case strings.ToLower(answer) == "n" && next != nil:
+				entry.category, entry.name = eventCategory(next), next.Summary
+			case strings.ToLower(answer) == "s" || answer == "":
+			default:
+				fields := strings.Fields(answer)
+				entry.category = fields[0]
+				entry.name = strings.Join(fields[1:], " ")
+				if entry.name == "" {
+					entry.name = strings.ToUpper(entry.category)
+				}
+				if len(fields[0]) == 1 {
+					// Probably a mistyped choice
+					colors.DisplayError("Unknown choice '" + answer + "'")
+					continue
+				}
+			}
+			if entry.name != "" {
+				entries = append(entries, entry)
+			}
+			break
+		}
+	}
+
+	if len(entries) == 0 {
+		colors.DisplayOk("Nothing to add")
+		return nil
+	}
+	for _, entry := range entries {
+		colors.DisplayOk(" + [ " + utilities.FormatTime(entry.slot.begin) + " -> " + utilities.FormatTime(entry.slot.end) + " ] [" + entry.category + "] : " + entry.name)
+	}
+	if !utilities.AskOkFromUser("Are you okay with adding those " + strconv.Itoa(len(entries)) + " events ?") {
+		colors.DisplayInfo("Aborting..")
+		return nil
+	}
+	var items []api.BatchItem
+	for _, entry := range entries {
+		items = append(items, api.InsertLoggedItem(entry.name, configuration.GetColorFromName(entry.category), entry.slot.begin, entry.slot.end))
+	}
+	nbErrors, _ := sendBatch(ctx, items, srv)
+	nbAdded := len(items) - nbErrors
+	colors.DisplayOk("Successfully filled " + strconv.Itoa(nbAdded) + " gaps !")
+	return nil
+}
@@ -0,0 +1,170 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	cmdOptions "github.com/lethenju/gogenda/internal/cmd_options"
+	"github.com/lethenju/gogenda/internal/configuration"
+	"github.com/lethenju/gogenda/internal/utilities"
+	"github.com/lethenju/gogenda/pkg/colors"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
+	"google.golang.org/api/calendar/v3"
+)
+
+// freeSlot is a free slot of time, as written in the json output
+type freeSlot struct {
+	Start   string  `json:"start"`
+	End     string  `json:"end"`
+	Minutes float64 `json:"minutes"`
+}
+
+// busyEvents returns the busy periods of the calendars as events, so they can be used like the events of the calendar
+func busyEvents(ctx context.Context, calendarIDs []string, begin time.Time, end time.Time, srv *calendar.Service) (events []*calendar.Event, err error) {
+	periods, err := api.GetBusyPeriods(ctx, calendarIDs, begin.Format(time.RFC3339), end.Format(time.RFC3339), srv)
+	if err != nil {
+		return nil, err
+	}
+	for _, period := range periods {
+		events = append(events, &calendar.Event{
+			Start: &calendar.EventDateTime{DateTime: period.Start},
+			End:   &calendar.EventDateTime{DateTime: period.End},
+		})
+	}
+	return events, nil
+}
+
+// findFreeSlots computes the free slots of the working days, in the hours given in parameters, from now on
+func findFreeSlots(begin time.Time, nbDays int, between []string, minDuration time.Duration, events []*calendar.Event) (slots []interval, err error) {
+	for day := begin; day.Before(begin.AddDate(0, 0, nbDays)); day = day.AddDate(0, 0, 1) {
+		slot, ok := hoursInterval(day, between[0], between[1])
+		if !ok {
+			return nil, errors.New("wrong hours '" + strings.Join(between, "-") + "', should be like 09:00-18:00")
+		}
+		if !isWorkingDay(day) {
+			continue
+		}
+		now := utilities.Now().In(day.Location())
+		if slot.begin.Before(now) {
+			// The past is not free anymore
+			slot.begin = now.Truncate(time.Minute)
+		}
+		if !slot.end.After(slot.begin) {
+			continue
+		}
+		slots = append(slots, findGaps(slot, events, minDuration)...)
+	}
+	return slots, nil
+}
+
+// freeCommand shows the free slots of time of the calendar, to plan things in them
+// free (date) (nb of days) --min 45m --between 09:00-18:00 --calendars primary,other --json
+func freeCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
+	command, options, err := cmdOptions.ExtractCommandOptions(command, []string{"min", "between", "calendars"}, []string{"json"})
+	if err != nil {
+		return err
+	}
+	period, err := utilities.PeriodParser(command[1:], utilities.Location(ctx))
+	if err != nil {
+		return err
+	}
+	begin, end := period.Begin, period.End
+
+	minDuration := 30 * time.Minute
+	if options["min"] != "" {
+		minDuration, err = time.ParseDuration(options["min"])
+		if err != nil || minDuration <= 0 {
+			return errors.New("Wrong duration '" + options["min"] + "', should be like 45m or 1h30m")
+		}
+	}
+	workingHours := configuration.GetWorkingHours()
+	between := []string{workingHours.Start, workingHours.End}
+	if options["between"] != "" {
+		between = strings.Split(options["between"], "-")
+		if len(between) != 2 {
+			return errors.New("wrong hours '" + options["between"] + "', should be like 09:00-18:00")
+		}
+	}
+
+	var events []*calendar.Event
+	if options["calendars"] != "" {
+		events, err = busyEvents(ctx, strings.Split(options["calendars"], ","), begin, end, srv)
+		if err != nil {
+			return err
+		}
+	} else {
+		cals, err := api.GetActivitiesBetweenDates(ctx, begin.Format(time.RFC3339), end.Format(time.RFC3339), srv)
+		if err != nil {
+			return err
+		}
+		events = cals.Items
+	}
+
+	slots, err := findFreeSlots(begin, period.Days(), between, minDuration, events)
+	if err != nil {
+		return err
+	}
+
+	if options["json"] != "" {
+		output := []freeSlot{}
+		for _, slot := range slots {
+			output = append(output, freeSlot{Start: slot.begin.Format(time.RFC3339), End: slot.end.Format(time.RFC3339), Minutes: slot.duration().Minutes()})
+		}
+		b, err := json.MarshalIndent(output, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
+	if len(slots) == 0 {
+		colors.DisplayOk("No free slots of " + minDuration.String() + " found")
+		return nil
+	}
+	var lastDay time.Time
+	var total time.Duration
+	for _, slot := range slots {
+		if slot.begin.Day() != lastDay.Day() || slot.begin.Month() != lastDay.Month() {
+			colors.DisplayInfoHeading(" Free slots of " + utilities.FormatDate(slot.begin))
+			lastDay = slot.begin
+		}
+		colors.DisplayOk(" [ " + utilities.FormatTime(slot.begin) + " -> " + utilities.FormatTime(slot.end) + " ] " + slot.duration().String())
+		total += slot.duration()
+	}
+	colors.DisplayOk("      Total : " + total.String())
+	return nil
+}
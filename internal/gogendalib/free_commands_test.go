@@ -0,0 +1,96 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lethenju/gogenda/internal/utilities"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestFindFreeSlots(t *testing.T) {
+	// On monday morning
+	oldNow := utilities.Now
+	defer func() { utilities.Now = oldNow }()
+	utilities.Now = func() time.Time { return at(0, 10, 30) }
+
+	tests := []struct {
+		name    string
+		begin   time.Time
+		nbDays  int
+		between []string
+		events  []*calendar.Event
+		want    []interval
+		wantErr bool
+	}{
+		{
+			name:    "from now on",
+			begin:   at(0, 0, 0),
+			nbDays:  1,
+			between: []string{"09:00", "18:00"},
+			events:  testEvents(at(0, 12, 0), at(0, 14, 0)),
+			want:    []interval{{at(0, 10, 30), at(0, 12, 0)}, {at(0, 14, 0), at(0, 18, 0)}},
+		},
+		{
+			name:    "past days",
+			begin:   at(-3, 0, 0),
+			nbDays:  2,
+			between: []string{"09:00", "18:00"},
+		},
+		{
+			name:    "weekend is not free",
+			begin:   at(1, 0, 0),
+			nbDays:  7,
+			between: []string{"09:00", "12:00"},
+			want: []interval{
+				{at(1, 9, 0), at(1, 12, 0)}, {at(2, 9, 0), at(2, 12, 0)}, {at(3, 9, 0), at(3, 12, 0)},
+				{at(4, 9, 0), at(4, 12, 0)}, {at(7, 9, 0), at(7, 12, 0)},
+			},
+		},
+		{
+			name:    "wrong hours",
+			begin:   at(1, 0, 0),
+			nbDays:  1,
+			between: []string{"18:00", "09:00"},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := findFreeSlots(test.begin, test.nbDays, test.between, 30*time.Minute, test.events)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got error %v, want error %v", err, test.wantErr)
+			}
+			sameIntervals(t, got, test.want)
+		})
+	}
+}
@@ -1,10 +1,9 @@
 package gogendalib
 
 import (
-	"errors"
+	"context"
 	"os"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
@@ -18,15 +17,21 @@ import (
 	"google.golang.org/api/calendar/v3"
 )
 
-func RenderGraphCompleteness(items []*calendar.Event) *charts.Bar {
+// sinceDate shows the day of the first event, for the titles of the graphs
+func sinceDate(items []*calendar.Event, location *time.Location) string {
+	startTime, _ := api.GetEventStart(items[0], location)
+	return utilities.FormatDate(startTime)
+}
+
+func RenderGraphCompleteness(items []*calendar.Event, location *time.Location) *charts.Bar {
 
 	var durationTotal []float64
 	for i := 0; i < 8; i++ {
 		durationTotal = append(durationTotal, 0)
 	}
 	for _, item := range items {
-		startTime, _ := time.Parse(time.RFC3339, item.Start.DateTime)
-		endTime, _ := time.Parse(time.RFC3339, item.End.DateTime)
+		startTime, _ := api.GetEventStart(item, location)
+		endTime, _ := api.GetEventEnd(item, location)
 		duration := endTime.Sub(startTime)
 		if duration > time.Hour*24 {
 			duration = 0
@@ -39,7 +44,7 @@ func RenderGraphCompleteness(items []*calendar.Event) *charts.Bar {
 	bar := charts.NewBar()
 	// set some global options like Title/Legend/ToolTip or anything else
 	bar.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
-		Title: "Completeness since " + items[0].Start.Date,
+		Title: "Completeness since " + sinceDate(items, location),
 	}), charts.WithToolboxOpts(opts.Toolbox{Show: true}),
 		charts.WithLegendOpts(opts.Legend{Right: "80%"}))
 
@@ -56,7 +61,7 @@ func RenderGraphCompleteness(items []*calendar.Event) *charts.Bar {
 	return bar
 }
 
-func RenderGraphCompletenessVsLastWeeks(items []*calendar.Event) *charts.Bar {
+func RenderGraphCompletenessVsLastWeeks(items []*calendar.Event, location *time.Location) *charts.Bar {
 
 	var durationTotal []float64
 	var durationTotal2 []float64 // last week
@@ -66,10 +71,10 @@ func RenderGraphCompletenessVsLastWeeks(items []*calendar.Event) *charts.Bar {
 		durationTotal2 = append(durationTotal2, 0)
 		durationTotal3 = append(durationTotal3, 0)
 	}
-	nowYear, nowWeek := time.Now().ISOWeek()
+	nowYear, nowWeek := time.Now().In(location).ISOWeek()
 	for _, item := range items {
-		startTime, _ := time.Parse(time.RFC3339, item.Start.DateTime)
-		endTime, _ := time.Parse(time.RFC3339, item.End.DateTime)
+		startTime, _ := api.GetEventStart(item, location)
+		endTime, _ := api.GetEventEnd(item, location)
 		duration := endTime.Sub(startTime)
 		if duration > time.Hour*24 {
 			duration = 0
@@ -92,7 +97,7 @@ func RenderGraphCompletenessVsLastWeeks(items []*calendar.Event) *charts.Bar {
 	bar := charts.NewBar()
 	// set some global options like Title/Legend/ToolTip or anything else
 	bar.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
-		Title: "Completeness since " + items[0].Start.Date,
+		Title: "Completeness since " + sinceDate(items, location),
 	}), charts.WithToolboxOpts(opts.Toolbox{Show: true}),
 		charts.WithLegendOpts(opts.Legend{Right: "80%"}))
 
@@ -117,7 +122,7 @@ func RenderGraphCompletenessVsLastWeeks(items []*calendar.Event) *charts.Bar {
 	return bar
 }
 
-func RenderGraphWorkVsLastWeek(items []*calendar.Event) *charts.Bar {
+func RenderGraphWorkVsLastWeek(items []*calendar.Event, location *time.Location) *charts.Bar {
 
 	// We have to put a default 0 value that is not the zero value of item.ColorId (which is ""
 	var durationWork []float64
@@ -130,11 +135,11 @@ func RenderGraphWorkVsLastWeek(items []*calendar.Event) *charts.Bar {
 		durationWorkLastWeek = append(durationWorkLastWeek, 0)
 		durationWorkLastWeek2 = append(durationWorkLastWeek2, 0)
 	}
-	nowYear, nowWeek := time.Now().ISOWeek()
+	nowYear, nowWeek := time.Now().In(location).ISOWeek()
 
 	for _, item := range items {
-		startTime, _ := time.Parse(time.RFC3339, item.Start.DateTime)
-		endTime, _ := time.Parse(time.RFC3339, item.End.DateTime)
+		startTime, _ := api.GetEventStart(item, location)
+		endTime, _ := api.GetEventEnd(item, location)
 		duration := endTime.Sub(startTime)
 		if duration > time.Hour*24 {
 			duration = 0
@@ -187,7 +192,7 @@ func RenderGraphWorkVsLastWeek(items []*calendar.Event) *charts.Bar {
 	return bar
 }
 
-func RenderGraphWorkVsPlay(items []*calendar.Event) *charts.Bar {
+func RenderGraphWorkVsPlay(items []*calendar.Event, location *time.Location) *charts.Bar {
 
 	// We have to put a default 0 value that is not the zero value of item.ColorId (which is ""
 	var durationWork []float64
@@ -197,8 +202,8 @@ func RenderGraphWorkVsPlay(items []*calendar.Event) *charts.Bar {
 		durationFun = append(durationFun, 0)
 	}
 	for _, item := range items {
-		startTime, _ := time.Parse(time.RFC3339, item.Start.DateTime)
-		endTime, _ := time.Parse(time.RFC3339, item.End.DateTime)
+		startTime, _ := api.GetEventStart(item, location)
+		endTime, _ := api.GetEventEnd(item, location)
 		duration := endTime.Sub(startTime)
 		if duration > time.Hour*24 {
 			duration = 0
@@ -221,7 +226,7 @@ func RenderGraphWorkVsPlay(items []*calendar.Event) *charts.Bar {
 	bar := charts.NewBar()
 	// set some global options like Title/Legend/ToolTip or anything else
 	bar.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
-		Title:    "Worktime vs Playtime since " + items[0].Start.Date,
+		Title:    "Worktime vs Playtime since " + sinceDate(items, location),
 		Subtitle: "In blue worktime, in green playtime",
 	}), charts.WithToolboxOpts(opts.Toolbox{Show: true}),
 		charts.WithLegendOpts(opts.Legend{Right: "80%"}))
@@ -244,21 +249,21 @@ func RenderGraphWorkVsPlay(items []*calendar.Event) *charts.Bar {
 	return bar
 }
 
-func RenderGraphWork(items []*calendar.Event) *charts.Line {
+func RenderGraphWork(items []*calendar.Event, location *time.Location) *charts.Line {
 	line := charts.NewLine()
 
 	x := make([]string, 0)
 	//y := make([]opts.LineData, 0)
 	y2 := make([]opts.LineData, 0)
 	y3 := make([]opts.LineData, 0)
-	actualDate, _ := time.Parse(time.RFC3339, items[0].Start.DateTime)
+	actualDate, _ := api.GetEventStart(items[0], location)
 	totalDuration := time.Duration(0)
 	totalDurationWork := time.Duration(0)
 	totalDurationFun := time.Duration(0)
 
 	for i := 0; i < len(items); i++ {
-		startTime, _ := time.Parse(time.RFC3339, items[i].Start.DateTime)
-		endTime, _ := time.Parse(time.RFC3339, items[i].End.DateTime)
+		startTime, _ := api.GetEventStart(items[i], location)
+		endTime, _ := api.GetEventEnd(items[i], location)
 		duration := endTime.Sub(startTime)
 		if duration > time.Hour*24 {
 			duration = 0
@@ -280,7 +285,7 @@ func RenderGraphWork(items []*calendar.Event) *charts.Line {
 			}
 		} else {
 			if totalDuration != 0 {
-				x = append(x, startTime.Format(time.RFC1123))
+				x = append(x, utilities.FormatDate(startTime))
 				//y = append(y, opts.LineData{Value: totalDuration.Hours()})
 				y2 = append(y2, opts.LineData{Value: (totalDurationWork.Hours() / totalDuration.Hours())})
 				y3 = append(y3, opts.LineData{Value: (totalDurationFun.Hours() / totalDuration.Hours())})
@@ -333,14 +338,14 @@ type ActivityType struct {
 	timeSpent float64
 }
 
-func getMostRecurrentEventsByCategory(items []*calendar.Event, category string) []ActivityType {
+func getMostRecurrentEventsByCategory(items []*calendar.Event, location *time.Location, category string) []ActivityType {
 
 	// We have to put a default 0 value that is not the zero value of item.ColorId (which is ""
 	var events []ActivityType
 
 	for _, item := range items {
-		startTime, _ := time.Parse(time.RFC3339, item.Start.DateTime)
-		endTime, _ := time.Parse(time.RFC3339, item.End.DateTime)
+		startTime, _ := api.GetEventStart(item, location)
+		endTime, _ := api.GetEventEnd(item, location)
 		duration := endTime.Sub(startTime)
 		// retrieve category
 		colorName, _ := api.GetColorNameFromColorID(item.ColorId)
@@ -374,15 +379,15 @@ func getMostRecurrentEventsByCategory(items []*calendar.Event, category string)
 	return events
 }
 
-func RenderGraphMostRecurrentMeals(items []*calendar.Event) *charts.Bar {
+func RenderGraphMostRecurrentMeals(items []*calendar.Event, location *time.Location) *charts.Bar {
 
-	meals := getMostRecurrentEventsByCategory(items, "LUNCH")
+	meals := getMostRecurrentEventsByCategory(items, location, "LUNCH")
 
 	// create a new bar instance
 	bar := charts.NewBar()
 	// set some global options like Title/Legend/ToolTip or anything else
 	bar.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
-		Title: "Most common meals since " + items[0].Start.Date,
+		Title: "Most common meals since " + sinceDate(items, location),
 	}), charts.WithToolboxOpts(opts.Toolbox{Show: true}),
 		charts.WithLegendOpts(opts.Legend{Right: "80%"}),
 		charts.WithDataZoomOpts(opts.DataZoom{
@@ -410,9 +415,9 @@ func RenderGraphMostRecurrentMeals(items []*calendar.Event) *charts.Bar {
 	return bar
 }
 
-func RenderGraphMostRecurrentFunActivities(items []*calendar.Event) *charts.Bar {
+func RenderGraphMostRecurrentFunActivities(items []*calendar.Event, location *time.Location) *charts.Bar {
 
-	activities := getMostRecurrentEventsByCategory(items, "FUN")
+	activities := getMostRecurrentEventsByCategory(items, location, "FUN")
 
 	// re-sort by time spent
 	sort.Slice(activities, func(p, q int) bool {
@@ -423,7 +428,7 @@ func RenderGraphMostRecurrentFunActivities(items []*calendar.Event) *charts.Bar
 	bar := charts.NewBar()
 	// set some global options like Title/Legend/ToolTip or anything else
 	bar.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
-		Title: "Most time consuming activities since " + items[0].Start.Date,
+		Title: "Most time consuming activities since " + sinceDate(items, location),
 	}), charts.WithToolboxOpts(opts.Toolbox{Show: true}),
 		charts.WithLegendOpts(opts.Legend{Right: "80%"}),
 		charts.WithDataZoomOpts(opts.DataZoom{
@@ -451,9 +456,9 @@ func RenderGraphMostRecurrentFunActivities(items []*calendar.Event) *charts.Bar
 	return bar
 }
 
-func RenderGraphMostRecurrentProjectActivities(items []*calendar.Event) *charts.Bar {
+func RenderGraphMostRecurrentProjectActivities(items []*calendar.Event, location *time.Location) *charts.Bar {
 
-	activities := getMostRecurrentEventsByCategory(items, "PROJECT")
+	activities := getMostRecurrentEventsByCategory(items, location, "PROJECT")
 
 	// re-sort by time spent
 	sort.Slice(activities, func(p, q int) bool {
@@ -464,7 +469,7 @@ func RenderGraphMostRecurrentProjectActivities(items []*calendar.Event) *charts.
 	bar := charts.NewBar()
 	// set some global options like Title/Legend/ToolTip or anything else
 	bar.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
-		Title: "Most common project since " + items[0].Start.Date,
+		Title: "Most common project since " + sinceDate(items, location),
 	}), charts.WithToolboxOpts(opts.Toolbox{Show: true}),
 		charts.WithLegendOpts(opts.Legend{Right: "80%"}),
 		charts.WithDataZoomOpts(opts.DataZoom{
@@ -491,78 +496,44 @@ func RenderGraphMostRecurrentProjectActivities(items []*calendar.Event) *charts.
 		AddSeries("Activities", itemsActivity)
 	return bar
 }
-func GraphCommand(command Command, srv *calendar.Service) (err error) {
-	// Get plan of all day
-	begin := time.Now()
-	begin = time.Date(begin.Year(), begin.Month(), begin.Day(), 0, 0, 0, 0, time.Local)
-	if len(command) > 1 {
-		begin, err = utilities.DateParser(command[1])
-		if err != nil {
-			return err
-		}
-
+func GraphCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
+	location := utilities.Location(ctx)
+	// Get plan of the period, all day by default
+	period, err := utilities.PeriodParser(command[1:], location)
+	if err != nil {
+		return err
 	}
+	begin, end := period.Begin, period.End
 
-	nbDays := 1
-	if len(command) > 2 {
-		// Number of days to do
-		nbDays, err = strconv.Atoi(command[2])
-		if err != nil {
-			return errors.New("Wrong argument '" + command[2] + "', should be a number")
-		}
-	}
-	end := begin.Add(time.Duration(24*nbDays) * time.Hour)
-
-	// If there are too much time between dates, need to ask several times : ask for every 30 days
-	nbAsks := (int(end.Sub(begin).Hours()/24) / 30) + 1
+	// The events are fetched by chunks, and all day events would count as whole days of activity
 	var items []*calendar.Event
-
-	if nbAsks == 1 {
-		events, err := api.GetActivitiesBetweenDates(
-			begin.Format(time.RFC3339),
-			end.Format(time.RFC3339), srv)
-		if err != nil {
-			return err
+	it := api.NewEventIterator(ctx, begin, end, srv)
+	defer it.Close()
+	for it.Next() {
+		if !api.IsAllDay(it.Event()) {
+			items = append(items, it.Event())
 		}
-
-		items = append(items, events.Items...)
-	} else {
-
-		for i := 1; i < nbAsks; i++ {
-			colors.DisplayInfo("Asking for 30 days (i=" + strconv.Itoa(i) + ") - [" + begin.Add(time.Hour*time.Duration(24*30*(i-1))).Format(time.RFC3339) + "] -> [" + begin.Add(time.Hour*time.Duration(24*30*i)).Format(time.RFC3339) + "]")
-
-			//Asking for 30 days
-			events, err := api.GetActivitiesBetweenDates(
-				begin.Add(time.Hour*time.Duration(24*30*(i-1))).Format(time.RFC3339),
-				begin.Add(time.Hour*time.Duration(24*30*i)).Format(time.RFC3339), srv)
-			if err != nil {
-				return err
-			}
-			items = append(items, events.Items...)
-		}
-		// Asking for the remaining days
-		colors.DisplayInfo("Asking for the remaining days - [" + begin.Add(time.Hour*time.Duration(24*120*(nbAsks-1))).Format(time.RFC3339) + "] -> [" + end.Format(time.RFC3339) + "]")
-		events, err := api.GetActivitiesBetweenDates(
-			begin.Add(time.Hour*time.Duration(24*120*(nbAsks-1))).Format(time.RFC3339),
-			end.Format(time.RFC3339), srv)
-		if err != nil {
-			return err
-		}
-		items = append(items, events.Items...)
+	}
+	if it.Err() != nil {
+		return it.Err()
+	}
+	if len(items) == 0 {
+		colors.DisplayOk("No events found")
+		return nil
 	}
 
 	page := components.NewPage()
 	page.Layout = components.PageFlexLayout
 	page.AddCharts(
-		RenderGraphWorkVsPlay(items),
-		RenderGraphWorkVsLastWeek(items),
-		RenderGraphCompleteness(items),
-		RenderGraphCompletenessVsLastWeeks(items),
-		RenderGraphMostRecurrentMeals(items),
-		RenderGraphMostRecurrentFunActivities(items),
-		//RenderGraphMostRecurrentWorkActivities(items),
-		RenderGraphMostRecurrentProjectActivities(items),
-		RenderGraphWork(items),
+		RenderGraphWorkVsPlay(items, location),
+		RenderGraphWorkVsLastWeek(items, location),
+		RenderGraphCompleteness(items, location),
+		RenderGraphCompletenessVsLastWeeks(items, location),
+		RenderGraphMostRecurrentMeals(items, location),
+		RenderGraphMostRecurrentFunActivities(items, location),
+		//RenderGraphMostRecurrentWorkActivities(items, location),
+		RenderGraphMostRecurrentProjectActivities(items, location),
+		RenderGraphWork(items, location),
 	)
 	// Where the magic happens
 	f, _ := os.Create("page.html")
@@ -31,6 +31,7 @@ package gogendalib
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -48,12 +49,37 @@ import (
 // Command : A command as a suite of arguments given by the user
 type Command []string
 
+// extractEstimate extracts the estimate of the command, given like "~2h" or "~1h30m"
+// Returns the command without it, and 0 if there is no estimate
+func extractEstimate(command Command) (Command, time.Duration, error) {
+	var rest Command
+	var estimate time.Duration
+	for i, word := range command {
+		if i > 0 && strings.HasPrefix(word, "~") && estimate == 0 {
+			duration, err := time.ParseDuration(word[1:])
+			if err != nil || duration <= 0 {
+				return command, 0, errors.New("Wrong estimate '" + word + "', should be like ~2h or ~1h30m")
+			}
+			estimate = duration
+			continue
+		}
+		rest = append(rest, word)
+	}
+	return rest, estimate, nil
+}
+
 // Add an event now
-func startCommand(command Command, srv *calendar.Service) (err error) {
+func startCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
 	var nameOfEvent string
+	command, estimate, err := extractEstimate(command)
+	if err != nil {
+		return err
+	}
+	if len(command) < 2 {
+		return errors.New("Missing the name of the activity")
+	}
 	color := configuration.GetColorFromName(command[1])
 	if len(command) == 2 && color != "blue" {
-		fmt.Print(command)
 		fmt.Print("Enter name of event :")
 		scanner := bufio.NewScanner(os.Stdin)
 		if !scanner.Scan() {
@@ -62,10 +88,13 @@ func startCommand(command Command, srv *calendar.Service) (err error) {
 		nameOfEvent = scanner.Text()
 		currentActivity, err := current_activity.GetCurrentActivity()
 		if err == nil {
-			// Stop the current activity
-			err = api.StopActivity(currentActivity, srv)
+			// Stop the current activity, the new one is not started if it keeps running
+			err = ResolveEditConflict(ctx, api.StopActivity(ctx, currentActivity, srv), srv)
 			if err != nil {
-				colors.DisplayError("There was an issue deleting the current event.")
+				if err != ErrAborted {
+					colors.DisplayError("There was an issue stopping the current activity.")
+				}
+				return err
 			}
 		}
 	} else if len(command) == 2 {
@@ -74,7 +103,12 @@ func startCommand(command Command, srv *calendar.Service) (err error) {
 		nameOfEvent = strings.Join(command[2:], " ")
 	}
 
-	currentActivity, err := api.InsertActivity(nameOfEvent, color, time.Now(), time.Now().Add(30*time.Minute), srv)
+	var currentActivity calendar.Event
+	if estimate > 0 {
+		currentActivity, err = api.InsertEstimatedActivity(ctx, nameOfEvent, color, time.Now(), time.Now().Add(30*time.Minute), estimate, srv)
+	} else {
+		currentActivity, err = api.InsertActivity(ctx, nameOfEvent, color, time.Now(), time.Now().Add(30*time.Minute), srv)
+	}
 	if err != nil {
 		return err
 	}
@@ -84,7 +118,7 @@ func startCommand(command Command, srv *calendar.Service) (err error) {
 	return nil
 }
 
-func stopCommand(srv *calendar.Service) (err error) {
+func stopCommand(ctx context.Context, srv *calendar.Service) (err error) {
 
 	currentActivity, err := current_activity.GetCurrentActivity()
 	if err != nil {
@@ -99,21 +133,32 @@ func stopCommand(srv *calendar.Service) (err error) {
 	if err != nil {
 		return err
 	}
-	err = api.StopActivity(currentActivity, srv)
+	err = api.StopActivity(ctx, currentActivity, srv)
+	if conflict, isConflict := err.(*api.ConflictError); isConflict {
+		overwrite, ok := askConflictResolution(conflict)
+		if !ok {
+			// The activity goes on
+			return nil
+		}
+		_, err = api.ResolveConflict(ctx, conflict, overwrite, srv)
+	}
+	if err != nil {
+		return err
+	}
 
 	current_activity.SetCurrentActivity(nil)
 
 	colors.DisplayOk("Successfully stopped the activity ! I hope it went well ")
-	return err
+	return nil
 }
 
-func deleteCommand(srv *calendar.Service) (err error) {
+func deleteCommand(ctx context.Context, srv *calendar.Service) (err error) {
 
 	currentActivity, err := current_activity.GetCurrentActivity()
 	if err != nil {
 		return errors.New("nothing to delete")
 	}
-	err = api.DeleteActivity(currentActivity, srv)
+	err = api.DeleteActivity(ctx, currentActivity, srv)
 	if err != nil {
 		return err
 	}
@@ -121,7 +166,7 @@ func deleteCommand(srv *calendar.Service) (err error) {
 	return nil
 }
 
-func renameCommand(command Command, srv *calendar.Service) (err error) {
+func renameCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
 	currentActivity, err := current_activity.GetCurrentActivity()
 	if err != nil {
 		return errors.New("nothing to rename")
@@ -132,7 +177,7 @@ func renameCommand(command Command, srv *calendar.Service) (err error) {
 	} else {
 		nameOfEvent = strings.Join(command[1:], " ")
 	}
-	err = api.RenameActivity(currentActivity, nameOfEvent, srv)
+	err = ResolveEditConflict(ctx, api.RenameActivity(ctx, currentActivity, nameOfEvent, srv), srv)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,143 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lethenju/gogenda/internal/configuration"
+	"github.com/lethenju/gogenda/internal/utilities"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
+	"google.golang.org/api/calendar/v3"
+)
+
+// errorWrongWorkingHours is returned when the working hours of the configuration cannot be used
+var errorWrongWorkingHours = errors.New("wrong working hours in the configuration, they should be like \"09:00\" to \"18:00\"")
+
+// interval is a slot of time, from begin (included) to end (excluded)
+type interval struct {
+	begin time.Time
+	end   time.Time
+}
+
+// duration returns the duration of the interval
+func (i interval) duration() time.Duration {
+	return i.end.Sub(i.begin)
+}
+
+// intersection returns the slot of time shared by both intervals, false if they don't overlap
+func (i interval) intersection(other interval) (interval, bool) {
+	shared := i
+	if other.begin.After(shared.begin) {
+		shared.begin = other.begin
+	}
+	if other.end.Before(shared.end) {
+		shared.end = other.end
+	}
+	return shared, shared.begin.Before(shared.end)
+}
+
+// eventInterval returns the slot of time taken by an event in the time zone given in parameters, false for all day events
+func eventInterval(event *calendar.Event, location *time.Location) (interval, bool) {
+	if event.Start == nil || event.End == nil || event.Start.DateTime == "" {
+		return interval{}, false
+	}
+	begin, _ := api.GetEventStart(event, location)
+	end, _ := api.GetEventEnd(event, location)
+	return interval{begin: begin, end: end}, true
+}
+
+// workingInterval returns the working hours of the day given in parameters, false if it is not a working day
+func workingInterval(day time.Time) (interval, bool) {
+	if !isWorkingDay(day) {
+		return interval{}, false
+	}
+	workingHours := configuration.GetWorkingHours()
+	return hoursInterval(day, workingHours.Start, workingHours.End)
+}
+
+// isWorkingDay returns true if the day given in parameters is one of the working days
+func isWorkingDay(day time.Time) bool {
+	for _, workingDay := range configuration.GetWorkingHours().Days {
+		if strings.HasPrefix(strings.ToLower(day.Weekday().String()), strings.ToLower(workingDay)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hoursInterval returns the interval between the two times ("HH:MM") on the day given in parameters, in its time zone
+func hoursInterval(day time.Time, start string, end string) (interval, bool) {
+	startTime, err := utilities.TimeParser(start, day.Location())
+	if err != nil {
+		return interval{}, false
+	}
+	endTime, err := utilities.TimeParser(end, day.Location())
+	if err != nil {
+		return interval{}, false
+	}
+	return interval{
+		begin: time.Date(day.Year(), day.Month(), day.Day(), startTime.Hour(), startTime.Minute(), 0, 0, day.Location()),
+		end:   time.Date(day.Year(), day.Month(), day.Day(), endTime.Hour(), endTime.Minute(), 0, 0, day.Location()),
+	}, startTime.Before(endTime)
+}
+
+// findGaps returns the slots of the interval that no event covers, at least minDuration long
+func findGaps(slot interval, events []*calendar.Event, minDuration time.Duration) (gaps []interval) {
+	var busy []interval
+	for _, event := range events {
+		if i, ok := eventInterval(event, slot.begin.Location()); ok && i.end.After(i.begin) {
+			busy = append(busy, i)
+		}
+	}
+	sort.Slice(busy, func(p, q int) bool {
+		return busy[p].begin.Before(busy[q].begin)
+	})
+	current := slot.begin
+	for _, b := range busy {
+		if !b.end.After(current) {
+			continue
+		}
+		if !b.begin.Before(slot.end) {
+			break
+		}
+		if b.begin.After(current) && b.begin.Sub(current) >= minDuration {
+			gaps = append(gaps, interval{begin: current, end: b.begin})
+		}
+		current = b.end
+	}
+	if slot.end.After(current) && slot.end.Sub(current) >= minDuration {
+		gaps = append(gaps, interval{begin: current, end: slot.end})
+	}
+	return gaps
+}
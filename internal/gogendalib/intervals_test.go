@@ -0,0 +1,98 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// sameIntervals reports an error if the intervals are not the ones wanted
+func sameIntervals(t *testing.T, got []interval, want []interval) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d intervals %v, want %v", len(got), got, want)
+	}
+	for i := range got {
+		if !got[i].begin.Equal(want[i].begin) || !got[i].end.Equal(want[i].end) {
+			t.Errorf("interval %d is %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFindGaps(t *testing.T) {
+	day := interval{at(0, 9, 0), at(0, 18, 0)}
+	tests := []struct {
+		name        string
+		events      []*calendar.Event
+		minDuration time.Duration
+		want        []interval
+	}{
+		{name: "no events", want: []interval{day}},
+		{name: "covered", events: testEvents(at(0, 8, 0), at(0, 19, 0))},
+		{
+			name:   "between events",
+			events: testEvents(at(0, 9, 0), at(0, 12, 0), at(0, 14, 0), at(0, 18, 0)),
+			want:   []interval{{at(0, 12, 0), at(0, 14, 0)}},
+		},
+		{
+			name:   "unsorted events",
+			events: testEvents(at(0, 14, 0), at(0, 18, 0), at(0, 9, 0), at(0, 12, 0)),
+			want:   []interval{{at(0, 12, 0), at(0, 14, 0)}},
+		},
+		{
+			name:   "overlapping events",
+			events: testEvents(at(0, 9, 0), at(0, 13, 0), at(0, 10, 0), at(0, 11, 0), at(0, 15, 0), at(0, 18, 0)),
+			want:   []interval{{at(0, 13, 0), at(0, 15, 0)}},
+		},
+		{
+			name:   "events outside",
+			events: testEvents(at(0, 7, 0), at(0, 10, 0), at(0, 17, 0), at(0, 20, 0)),
+			want:   []interval{{at(0, 10, 0), at(0, 17, 0)}},
+		},
+		{
+			name:        "too short",
+			events:      testEvents(at(0, 9, 0), at(0, 12, 0), at(0, 12, 20), at(0, 18, 0)),
+			minDuration: 30 * time.Minute,
+		},
+		{
+			name:   "zero length events are ignored",
+			events: testEvents(at(0, 12, 0), at(0, 12, 0)),
+			want:   []interval{day},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sameIntervals(t, findGaps(day, test.events, test.minDuration), test.want)
+		})
+	}
+}
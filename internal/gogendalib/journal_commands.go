@@ -0,0 +1,127 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lethenju/gogenda/internal/utilities"
+	"github.com/lethenju/gogenda/pkg/colors"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
+	"google.golang.org/api/calendar/v3"
+)
+
+// describeEvent gives a one line description of an event in the time zone given in parameters, for the history
+func describeEvent(event *calendar.Event, location *time.Location) string {
+	if event == nil {
+		return ""
+	}
+	return "'" + event.Summary + "' [ " + describeEventTime(event, true, location) + " ]"
+}
+
+// describeMutation gives a one line description of a mutation of the journal, in the time zone given in parameters
+func describeMutation(entry api.JournalEntry, location *time.Location) string {
+	description := utilities.FormatDateTime(entry.Date.In(location)) + " " + strings.ToUpper(entry.Operation) + " "
+	switch entry.Operation {
+	case api.OperationUpdate:
+		description += describeEvent(entry.Before, location) + " => " + describeEvent(entry.After, location)
+	default:
+		description += describeEvent(entry.Event(), location)
+	}
+	return description
+}
+
+// historyCommand shows the last mutations done on the calendar
+func historyCommand(ctx context.Context, command Command) (err error) {
+	nb := 20
+	if len(command) > 1 {
+		nb, err = strconv.Atoi(command[1])
+		if err != nil {
+			return errors.New("Wrong argument '" + command[1] + "', should be a number")
+		}
+	}
+	journal, err := api.LoadJournal()
+	if err != nil {
+		return err
+	}
+	if len(journal) == 0 {
+		colors.DisplayOk("No history yet")
+		return nil
+	}
+	if len(journal) > nb {
+		journal = journal[len(journal)-nb:]
+	}
+	for i := len(journal) - 1; i >= 0; i-- {
+		if journal[i].Undone {
+			colors.DisplayInfo(" (undone) " + describeMutation(journal[i], utilities.Location(ctx)))
+		} else {
+			colors.DisplayOk(" " + describeMutation(journal[i], utilities.Location(ctx)))
+		}
+	}
+	return nil
+}
+
+// undoCommand reverts the last mutations done on the calendar
+func undoCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
+	nb := 1
+	if len(command) > 1 {
+		nb, err = strconv.Atoi(command[1])
+		if err != nil || nb < 1 {
+			return errors.New("Wrong argument '" + command[1] + "', should be a positive number")
+		}
+	}
+	entries, err := api.GetUndoableMutations(nb)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		colors.DisplayOk("Nothing to undo")
+		return nil
+	}
+	colors.DisplayOk("Undoing :")
+	for _, entry := range entries {
+		colors.DisplayOk(" " + describeMutation(entry, utilities.Location(ctx)))
+	}
+	isOkay := utilities.AskOkFromUser("Are you okay with that operation ?")
+	if !isOkay {
+		colors.DisplayInfo("Aborting..")
+		return nil
+	}
+	undone, err := api.UndoLastMutations(ctx, len(entries), askConflictResolution, srv)
+	if err != nil {
+		return errors.New("undid " + strconv.Itoa(len(undone)) + " operations, then failed : " + err.Error())
+	}
+	colors.DisplayOk("Successfully undid " + strconv.Itoa(len(undone)) + " operations !")
+	return nil
+}
@@ -0,0 +1,322 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lethenju/gogenda/internal/configuration"
+	"github.com/lethenju/gogenda/internal/utilities"
+	"github.com/lethenju/gogenda/pkg/colors"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
+	"google.golang.org/api/calendar/v3"
+)
+
+// Kinds of problems found by the lint command
+const (
+	lintOverlap    = "Overlaps"
+	lintZeroLength = "Zero-length events"
+	lintRunaway    = "Events over 24 hours"
+	lintGap        = "Gaps during working hours"
+)
+
+// Gaps shorter than that are not reported
+const lintMinGap = 15 * time.Minute
+
+// lintProblem is a problem found in the calendar : the events involved, or the slot of time for gaps
+type lintProblem struct {
+	kind   string
+	events []int
+	slot   interval
+}
+
+// lintReference gives the reference of an event as it is displayed by lint, in the time zone given in parameters
+func lintReference(index int, event *calendar.Event, location *time.Location) string {
+	return "[" + strconv.Itoa(index) + "] " + describeEvent(event, location)
+}
+
+// findLintProblems finds the overlaps, zero-length events, runaway events and gaps
+// during working hours (until now) of the events given in parameters, from the day begin for nbDays days
+// in the time zone of begin
+func findLintProblems(events []*calendar.Event, begin time.Time, nbDays int) (problems []lintProblem) {
+	location := begin.Location()
+	var valid []int
+	for i, event := range events {
+		slot, ok := eventInterval(event, location)
+		if !ok {
+			continue
+		}
+		if !slot.end.After(slot.begin) {
+			problems = append(problems, lintProblem{kind: lintZeroLength, events: []int{i}, slot: slot})
+		} else if slot.duration() > 24*time.Hour {
+			problems = append(problems, lintProblem{kind: lintRunaway, events: []int{i}, slot: slot})
+		} else {
+			valid = append(valid, i)
+		}
+	}
+	// Events are sorted by start time
+	for p, i := range valid {
+		slotI, _ := eventInterval(events[i], location)
+		for _, j := range valid[p+1:] {
+			slotJ, _ := eventInterval(events[j], location)
+			if !slotJ.begin.Before(slotI.end) {
+				break
+			}
+			slot, _ := slotI.intersection(slotJ)
+			problems = append(problems, lintProblem{kind: lintOverlap, events: []int{i, j}, slot: slot})
+		}
+	}
+	now := time.Now().In(location)
+	for day := begin; day.Before(begin.AddDate(0, 0, nbDays)); day = day.AddDate(0, 0, 1) {
+		slot, ok := workingInterval(day)
+		if !ok || !slot.begin.Before(now) {
+			continue
+		}
+		if slot.end.After(now) {
+			slot.end = now
+		}
+		for _, gap := range findGaps(slot, events, lintMinGap) {
+			problems = append(problems, lintProblem{kind: lintGap, slot: gap})
+		}
+	}
+	return problems
+}
+
+// lintCommand reports the problems of the logged history : overlaps, zero-length events, events over 24 hours,
+// and gaps during working hours. In fix mode, a repair is proposed for each of them
+func lintCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
+	fix := false
+	if len(command) > 1 && strings.ToUpper(command[1]) == "FIX" {
+		fix = true
+		command = command[1:]
+	}
+	location := utilities.Location(ctx)
+	period, err := utilities.PeriodParser(command[1:], location)
+	if err != nil {
+		return err
+	}
+	begin, end := period.Begin, period.End
+
+	cals, err := api.GetActivitiesBetweenDates(ctx, begin.Format(time.RFC3339), end.Format(time.RFC3339), srv)
+	if err != nil {
+		return err
+	}
+	events := cals.Items
+
+	// The references can be used with the plan commands
+	var planBuffer utilities.Plan
+	for _, event := range events {
+		planBuffer.Events = append(planBuffer.Events, utilities.EventStored{Name: event.Summary, CalendarID: event.Id, RecurringEventID: event.RecurringEventId})
+	}
+	utilities.StorePlan(&planBuffer)
+
+	problems := findLintProblems(events, begin, period.Days())
+	if len(problems) == 0 {
+		colors.DisplayOk("No problems found !")
+		return nil
+	}
+	for _, kind := range []string{lintOverlap, lintZeroLength, lintRunaway, lintGap} {
+		isFirst := true
+		for _, problem := range problems {
+			if problem.kind != kind {
+				continue
+			}
+			if isFirst {
+				colors.DisplayInfoHeading("=== " + kind + " ===")
+				isFirst = false
+			}
+			switch kind {
+			case lintOverlap:
+				colors.DisplayOk(" " + lintReference(problem.events[0], events[problem.events[0]], location) + " and " +
+					lintReference(problem.events[1], events[problem.events[1]], location) + " overlap for " + problem.slot.duration().String())
+			case lintGap:
+				colors.DisplayOk(" [ " + utilities.FormatDateTime(problem.slot.begin) + " -> " + utilities.FormatTime(problem.slot.end) + " ] " + problem.slot.duration().Truncate(time.Minute).String() + " untracked")
+			default:
+				colors.DisplayOk(" " + lintReference(problem.events[0], events[problem.events[0]], location) + " lasts " + problem.slot.duration().String())
+			}
+		}
+	}
+	colors.DisplayInfo(strconv.Itoa(len(problems)) + " problems found")
+	if !fix {
+		colors.DisplayInfo("Use 'lint fix' to repair them, or the 'plan' commands with the ids above")
+		return nil
+	}
+
+	nbFixed := 0
+	for _, problem := range problems {
+		// The previous fixes may have changed the events of this problem
+		problem, ok := refreshLintProblem(problem, events)
+		if !ok {
+			continue
+		}
+		fixed, err := fixLintProblem(ctx, problem, events, srv)
+		if err != nil {
+			colors.DisplayError("Could not fix it : " + err.Error())
+		}
+		if fixed {
+			nbFixed++
+		}
+	}
+	colors.DisplayOk("Fixed " + strconv.Itoa(nbFixed) + " problems !")
+	return nil
+}
+
+// refreshLintProblem gives the problem as it is now that the previous fixes changed the events in place.
+// Returns false if it has been solved meanwhile, or if one of its events has been deleted
+func refreshLintProblem(problem lintProblem, events []*calendar.Event) (lintProblem, bool) {
+	var slots []interval
+	for _, i := range problem.events {
+		slot, ok := eventInterval(events[i], problem.slot.begin.Location())
+		if events[i].Id == "" || !ok {
+			return problem, false
+		}
+		slots = append(slots, slot)
+	}
+	switch problem.kind {
+	case lintOverlap:
+		slot, ok := slots[0].intersection(slots[1])
+		problem.slot = slot
+		return problem, ok
+	case lintZeroLength:
+		problem.slot = slots[0]
+		return problem, !slots[0].end.After(slots[0].begin)
+	case lintRunaway:
+		problem.slot = slots[0]
+		return problem, slots[0].duration() > 24*time.Hour
+	case lintGap:
+		var remaining []*calendar.Event
+		for _, event := range events {
+			if event.Id != "" {
+				remaining = append(remaining, event)
+			}
+		}
+		gaps := findGaps(problem.slot, remaining, lintMinGap)
+		if len(gaps) == 0 {
+			return problem, false
+		}
+		problem.slot = gaps[0]
+	}
+	return problem, true
+}
+
+// fixLintProblem proposes a repair for the problem and applies it if the user wants to.
+// The events changed are updated in place, the deleted ones lose their id.
+// Returns true if the problem has been fixed
+func fixLintProblem(ctx context.Context, problem lintProblem, events []*calendar.Event, srv *calendar.Service) (bool, error) {
+	location := utilities.Location(ctx)
+	switch problem.kind {
+	case lintOverlap:
+		first, second := events[problem.events[0]], events[problem.events[1]]
+		firstSlot, _ := eventInterval(first, location)
+		secondSlot, _ := eventInterval(second, location)
+		colors.DisplayInfoHeading(" " + lintReference(problem.events[0], first, location) + " and " + lintReference(problem.events[1], second, location) + " overlap")
+		for {
+			answer := strings.ToLower(utilities.InputFromUser("(t)rim the first one, (m)erge them in the first one, (d)elete the second one or (s)kip"))
+			switch answer {
+			case "t", "trim":
+				if !secondSlot.begin.After(firstSlot.begin) {
+					// They start at the same time, nothing would be left of the first one
+					if !utilities.AskOkFromUser("Nothing would be left of the first one, delete it ?") {
+						return false, nil
+					}
+					err := api.DeleteActivity(ctx, first, srv)
+					return err == nil, err
+				}
+				return lintFixed(resolveEventConflict(ctx, first, api.ResizeActivity(ctx, first, firstSlot.begin, secondSlot.begin, srv), srv))
+			case "m", "merge":
+				mergedEnd := firstSlot.end
+				if secondSlot.end.After(mergedEnd) {
+					mergedEnd = secondSlot.end
+				}
+				err := resolveEventConflict(ctx, first, api.ResizeActivity(ctx, first, firstSlot.begin, mergedEnd, srv), srv)
+				if err != nil {
+					return lintFixed(err)
+				}
+				err = api.DeleteActivity(ctx, second, srv)
+				return err == nil, err
+			case "d", "delete":
+				err := api.DeleteActivity(ctx, second, srv)
+				return err == nil, err
+			case "s", "skip":
+				return false, nil
+			}
+		}
+	case lintZeroLength:
+		event := events[problem.events[0]]
+		colors.DisplayInfoHeading(" " + lintReference(problem.events[0], event, location) + " lasts " + problem.slot.duration().String())
+		if !utilities.AskOkFromUser("Delete it ?") {
+			return false, nil
+		}
+		err := api.DeleteActivity(ctx, event, srv)
+		return err == nil, err
+	case lintRunaway:
+		event := events[problem.events[0]]
+		colors.DisplayInfoHeading(" " + lintReference(problem.events[0], event, location) + " lasts " + problem.slot.duration().String())
+		for {
+			answer := strings.ToLower(utilities.InputFromUser("(t)rim it to the end of its day, (d)elete it or (s)kip"))
+			switch answer {
+			case "t", "trim":
+				begin := problem.slot.begin
+				endOfDay := time.Date(begin.Year(), begin.Month(), begin.Day(), 0, 0, 0, 0, location).AddDate(0, 0, 1)
+				return lintFixed(resolveEventConflict(ctx, event, api.ResizeActivity(ctx, event, begin, endOfDay, srv), srv))
+			case "d", "delete":
+				err := api.DeleteActivity(ctx, event, srv)
+				return err == nil, err
+			case "s", "skip":
+				return false, nil
+			}
+		}
+	case lintGap:
+		colors.DisplayInfoHeading(" [ " + utilities.FormatDateTime(problem.slot.begin) + " -> " + utilities.FormatTime(problem.slot.end) + " ] is untracked")
+		category := utilities.InputFromUser("category to fill it with (empty to skip)")
+		if category == "" {
+			return false, nil
+		}
+		name := utilities.InputFromUser("name of event (empty for '" + category + "')")
+		if name == "" {
+			name = category
+		}
+		_, err := api.InsertActivity(ctx, name, configuration.GetColorFromName(category), problem.slot.begin, problem.slot.end, srv)
+		return err == nil, err
+	}
+	return false, nil
+}
+
+// lintFixed gives the result of a fix from the error of the change done.
+// Aborting the resolution of a conflict only leaves the problem as it is
+func lintFixed(err error) (bool, error) {
+	if err == ErrAborted {
+		return false, nil
+	}
+	return err == nil, err
+}
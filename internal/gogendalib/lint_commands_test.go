@@ -0,0 +1,132 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// at returns the time given in parameters, on monday the 2nd of march 2020 shifted by the number of days
+func at(days int, hour int, min int) time.Time {
+	return time.Date(2020, time.March, 2+days, hour, min, 0, 0, time.Local)
+}
+
+// testEvents returns events between the pairs of times given in parameters, with their index as id
+func testEvents(times ...time.Time) (events []*calendar.Event) {
+	for i := 0; i+1 < len(times); i += 2 {
+		events = append(events, &calendar.Event{
+			Id:      strconv.Itoa(i / 2),
+			Summary: "event " + strconv.Itoa(i/2),
+			Start:   &calendar.EventDateTime{DateTime: times[i].Format(time.RFC3339)},
+			End:     &calendar.EventDateTime{DateTime: times[i+1].Format(time.RFC3339)},
+		})
+	}
+	return events
+}
+
+func TestFindLintProblems(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []*calendar.Event
+		days   int
+		want   []lintProblem
+	}{
+		{
+			name:   "working day covered",
+			events: testEvents(at(0, 9, 0), at(0, 18, 0)),
+		},
+		{
+			name:   "overlap",
+			events: testEvents(at(0, 9, 0), at(0, 12, 0), at(0, 11, 0), at(0, 18, 0)),
+			want:   []lintProblem{{kind: lintOverlap, events: []int{0, 1}, slot: interval{at(0, 11, 0), at(0, 12, 0)}}},
+		},
+		{
+			name:   "same start",
+			events: testEvents(at(0, 9, 0), at(0, 18, 0), at(0, 9, 0), at(0, 10, 0)),
+			want:   []lintProblem{{kind: lintOverlap, events: []int{0, 1}, slot: interval{at(0, 9, 0), at(0, 10, 0)}}},
+		},
+		{
+			name:   "zero length",
+			events: testEvents(at(0, 9, 0), at(0, 18, 0), at(0, 18, 0), at(0, 18, 0)),
+			want:   []lintProblem{{kind: lintZeroLength, events: []int{1}, slot: interval{at(0, 18, 0), at(0, 18, 0)}}},
+		},
+		{
+			name:   "runaway",
+			events: testEvents(at(-1, 8, 0), at(0, 18, 0)),
+			want:   []lintProblem{{kind: lintRunaway, events: []int{0}, slot: interval{at(-1, 8, 0), at(0, 18, 0)}}},
+		},
+		{
+			name:   "gap",
+			events: testEvents(at(0, 9, 0), at(0, 12, 0), at(0, 13, 0), at(0, 18, 0)),
+			want:   []lintProblem{{kind: lintGap, slot: interval{at(0, 12, 0), at(0, 13, 0)}}},
+		},
+		{
+			name:   "gap too short",
+			events: testEvents(at(0, 9, 0), at(0, 12, 0), at(0, 12, 10), at(0, 18, 0)),
+		},
+		{
+			name:   "gaps at the edges of the day",
+			events: testEvents(at(0, 10, 0), at(0, 17, 0)),
+			want: []lintProblem{
+				{kind: lintGap, slot: interval{at(0, 9, 0), at(0, 10, 0)}},
+				{kind: lintGap, slot: interval{at(0, 17, 0), at(0, 18, 0)}},
+			},
+		},
+		{
+			name: "weekend is not a gap",
+			days: 7,
+			events: testEvents(at(0, 9, 0), at(0, 18, 0), at(1, 9, 0), at(1, 18, 0), at(2, 9, 0), at(2, 18, 0),
+				at(3, 9, 0), at(3, 18, 0), at(4, 9, 0), at(4, 18, 0)),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			days := test.days
+			if days == 0 {
+				days = 1
+			}
+			got := findLintProblems(test.events, at(0, 0, 0), days)
+			if len(got) != len(test.want) {
+				t.Fatalf("got %d problems %v, want %d", len(got), got, len(test.want))
+			}
+			for i := range got {
+				if got[i].kind != test.want[i].kind || !reflect.DeepEqual(got[i].events, test.want[i].events) ||
+					!got[i].slot.begin.Equal(test.want[i].slot.begin) || !got[i].slot.end.Equal(test.want[i].slot.end) {
+					t.Errorf("problem %d is %v, want %v", i, got[i], test.want[i])
+				}
+			}
+		})
+	}
+}
@@ -59,20 +59,37 @@ func helpCommand(command Command, isShell bool) {
 			fmt.Println(" gogenda -h              - shows the help")
 			fmt.Println(" gogenda -compact        - Have minimalist output")
 			fmt.Println(" gogenda -config='path'  - Use a custom config file (absolute path only)")
+			fmt.Println(" gogenda -no-cache       - Ask the events to google agenda instead of the local cache")
+			fmt.Println(" gogenda -profile=name   - Use another profile than the default one, with its own account and configuration")
+			fmt.Println(" gogenda -no-browser     - Log in from another machine, pasting back the address the browser was sent to")
 			fmt.Println("")
 		}
 		colors.DisplayInfoHeading(" = Commands = ")
+		colors.DisplayOk("Any command can be followed by --tz (timezone), like --tz Europe/Paris, to use another timezone")
 
 		config, _ := configuration.GetConfig()
 		for _, category := range config.Categories {
 			fmt.Println(prefix + " start " + category.Name + " - Add an event in " + category.Color)
 		}
+		fmt.Println(prefix + " start (category) ~2h (name) - Add an event with an estimate of the time it will take")
 		fmt.Println(prefix + " stop - Stop the current activity")
 		fmt.Println(prefix + " rename - Rename the current activity")
 		fmt.Println(prefix + " delete - Delete the current activity")
 		fmt.Println(prefix + " plan - See and manipulate your calendar as you want")
 		fmt.Println(prefix + " stats - shows statistics about your time spent in each category")
 		fmt.Println(prefix + " add - add an event to the planning. You can call it alone or with some params.")
+		fmt.Println(prefix + " lint - find overlaps, gaps, zero-length and runaway events in your calendar")
+		fmt.Println(prefix + " fill - fill the untracked gaps of your day")
+		fmt.Println(prefix + " free - find the free slots of time in your calendar")
+		fmt.Println(prefix + " schedule - place a list of tasks in your free time")
+		fmt.Println(prefix + " review - compare what you planned to what you actually did")
+		fmt.Println(prefix + " template - save your ideal day or week and apply it to other dates")
+		fmt.Println(prefix + " history - show the last changes done on your calendar")
+		fmt.Println(prefix + " cache - show or clear the local cache of your events")
+		fmt.Println(prefix + " sync - send the changes done offline")
+		fmt.Println(prefix + " auth - log in, show what gogenda is allowed to do on your calendar, or log out")
+		fmt.Println(prefix + " profile - manage the profiles, to keep several accounts (work, personal..) separate")
+		fmt.Println(prefix + " undo - revert the last changes done on your calendar")
 		fmt.Println(prefix + " help - show gogenda help (add a command name if you want specific command help)")
 	} else if strings.ToUpper(specificHelp) == "ADD" {
 		fmt.Println(prefix + " add - add an event to the planning. You can call it alone or with some params.")
@@ -93,12 +110,15 @@ func helpCommand(command Command, isShell bool) {
 		fmt.Println("  | (date) (time) (category)")
 		fmt.Println("  | (date) (time) (category) (name...)")
 		fmt.Println("  - (date) (category) (name...)")
+		fmt.Println("  | Any of them can be followed by a (recurrence) to repeat the event")
+		fmt.Println("  - (date)..(date) (category) (name...) - Add an all day event, like '2026-12-24..2026-12-26 OFF holidays'")
 	} else if strings.ToUpper(specificHelp) == "PLAN" {
 		fmt.Println(prefix + " plan - See and manipulate your calendar as you want")
 		fmt.Println("  | If you dont specify anything, it's an alias for 'plan show today 1'")
 		fmt.Println("  | plan show - show today's events with an ID associated for each event for modifying them")
 		fmt.Println("          - (date)              - Show any day's events")
 		fmt.Println("          - (date) (nb of days) - Show all events from the date for the number of days given")
+		fmt.Println("          - (period)            - Show all events of the period, like 'this week' or '2026-09'")
 		fmt.Println("  | plan rename - Rename an event given its id (shown by the 'plan show' command) and the new name")
 		fmt.Println("          - (id) (name...)")
 		fmt.Println("  | plan move - Move an event given its id (shown by the 'plan show' command)  to the new start date")
@@ -113,15 +133,123 @@ func helpCommand(command Command, isShell bool) {
 		fmt.Println("          - (id) (time) (date) ")
 		fmt.Println("  | plan rename - Deletes an event given its id (shown by the 'plan show' command)")
 		fmt.Println("          - (id)")
+		fmt.Println("  | plan edit-day - Edit all the events of a day in your $EDITOR, one line per event, like a 'git rebase -i'")
+		fmt.Println("          - (date)              - Change, reorder, delete or add lines 'start end CATEGORY summary'")
 	} else if strings.ToUpper(specificHelp) == "STATS" {
 		fmt.Println(prefix + " stats - shows statistics about your time spent in each category")
 		fmt.Println("  | The program will get you today's statistics if you don't specify a param")
 		fmt.Println("  - (date)")
+		fmt.Println("  - (date) (nb of days)")
+		fmt.Println("  - (period)")
+		fmt.Println("  | stats estimates - shows how accurate your estimates were, per category and per recurring task, for the activities done")
+		fmt.Println("          - (date)")
+		fmt.Println("          - (date) (nb of days)")
+		fmt.Println("          - (period)")
+	} else if strings.ToUpper(specificHelp) == "LINT" {
+		fmt.Println(prefix + " lint - find overlaps, gaps, zero-length and runaway events in your calendar")
+		fmt.Println("  | Gaps are looked for in the working hours of your config.json file (\"workingHours\")")
+		fmt.Println("  | The ids shown can be used with the 'plan' commands")
+		fmt.Println("  - (date)")
+		fmt.Println("  - (date) (nb of days)")
+		fmt.Println("  - (period)")
+		fmt.Println("  | lint fix - propose a repair for each problem : trim, merge, delete or fill the gap")
+		fmt.Println("          - (date)")
+		fmt.Println("          - (date) (nb of days)")
+		fmt.Println("          - (period)")
+	} else if strings.ToUpper(specificHelp) == "FILL" {
+		fmt.Println(prefix + " fill - walk the untracked gaps of the day in your working hours, and ask what you did for each one")
+		fmt.Println("  | The previous or next task can be chosen with one key. Events are added at the end, in one go")
+		fmt.Println("  - (date)")
+	} else if strings.ToUpper(specificHelp) == "FREE" {
+		fmt.Println(prefix + " free - find the free slots of time of your working days, from now on")
+		fmt.Println("  - (date)")
+		fmt.Println("  - (date) (nb of days)")
+		fmt.Println("  - (period)")
+		fmt.Println("  | Options, after the command :")
+		fmt.Println("          --min (duration)          - Minimum duration of a slot, like 45m or 1h30m (30m by default)")
+		fmt.Println("          --between (time)-(time)   - Hours to look into, like 09:00-18:00 (working hours by default)")
+		fmt.Println("          --calendars (id),(id)     - Look at the busy periods of several calendars, like primary,someone@gmail.com")
+		fmt.Println("          --json                    - Print the slots as json")
+	} else if strings.ToUpper(specificHelp) == "SCHEDULE" {
+		fmt.Println(prefix + " schedule - place a list of tasks in the free time of your working hours, and add them to your calendar")
+		fmt.Println("  | One task per line : estimate CATEGORY name [!priority] [@deadline], like '2h WORK refactor parser !1 @friday'")
+		fmt.Println("  | The priority goes from 1 (the highest) and is 3 by default. Tasks are asked if there is no file")
+		fmt.Println("  - (file)")
+		fmt.Println("  | Options, after the command :")
+		fmt.Println("          --days (nb of days)       - Number of days to plan, from today (5 by default)")
+		fmt.Println("          --max-block (duration)    - Maximum duration of a block (\"maxBlock\" in config.json, 2h by default)")
+	} else if strings.ToUpper(specificHelp) == "REVIEW" {
+		fmt.Println(prefix + " review - compare the time planned (with add, template or schedule) to the time logged (with start, fill..)")
+		fmt.Println("  | per category and per planned block. Big deviations are highlighted")
+		fmt.Println("  - (date)")
+		fmt.Println("  - (date) (nb of days)")
+		fmt.Println("  - (period)")
+	} else if strings.ToUpper(specificHelp) == "TEMPLATE" {
+		fmt.Println(prefix + " template - save your ideal day or week and apply it to other dates")
+		fmt.Println("  | Templates are stored in the templates folder of the profile (~/.gogenda/templates/(name).json")
+		fmt.Println("  | for the default one), you can also write them by hand")
+		fmt.Println("  | template list - list the stored templates")
+		fmt.Println("  | template show - show the blocks of a template")
+		fmt.Println("          - (name)")
+		fmt.Println("  | template save - save the events of a day (or several) as a template")
+		fmt.Println("          - (name) (date)")
+		fmt.Println("          - (name) (date) (nb of days)")
+		fmt.Println("  | template apply - add the blocks of a template, days with conflicting events are skipped")
+		fmt.Println("          - (name) (date)          - The template starts at that date")
+		fmt.Println("          - (name) (date)..(date)  - The template is repeated on all the days of the range")
+		fmt.Println("  | The days of the template follow each other from the first date, whatever its weekday :")
+		fmt.Println("  | apply a week template from the same weekday as the first day it was saved from")
+		fmt.Println("  | template delete - delete a template")
+		fmt.Println("          - (name)")
+	} else if strings.ToUpper(specificHelp) == "HISTORY" {
+		fmt.Println(prefix + " history - show the last changes done on your calendar, the most recent first")
+		fmt.Println("  | Every change done by gogenda is kept in journal.json, in the folder of the profile (~/.gogenda for the default one)")
+		fmt.Println("  - (nb of changes)")
+	} else if strings.ToUpper(specificHelp) == "SYNC" {
+		fmt.Println(prefix + " sync - send the changes done offline (start, stop, rename, add..), in the order they were done")
+		fmt.Println("  | They are kept in outbox.json, in the folder of the profile, and also sent by the next command that can reach google agenda")
+		fmt.Println("  | Meanwhile, plan show and stats include them")
+	} else if strings.ToUpper(specificHelp) == "CACHE" {
+		fmt.Println(prefix + " cache - your events from a year ago to 3 months ahead are kept in the cache folder of the profile, and only the changes are asked to google agenda")
+		fmt.Println("  | The events outside of these dates are asked to google agenda")
+		fmt.Println("  | Use 'gogenda -no-cache (command)' to ask all the events again for one command")
+		fmt.Println("  | cache status - show the size of the cache and when it last got changes")
+		fmt.Println("  | cache clear - remove the cache, it will be downloaded again by the next command")
+	} else if strings.ToUpper(specificHelp) == "AUTH" {
+		fmt.Println(prefix + " auth - manage the authorization of gogenda on your calendar")
+		fmt.Println("  | The token is kept in token.json, or encrypted as set by 'tokenStorage' in config.json")
+		fmt.Println("  | auth login - allow gogenda to access your calendar again, replacing the token")
+		fmt.Println("  | auth status - show the account, the permissions and the expiry of the token")
+		fmt.Println("  | auth logout - revoke the access of gogenda to your calendar, and delete the token")
+	} else if strings.ToUpper(specificHelp) == "PROFILE" {
+		fmt.Println(prefix + " profile - each profile has its own credentials, token, config.json, templates, history and cache")
+		fmt.Println("  | The default profile uses ~/.gogenda, the other ones ~/.gogenda/profiles/(name)")
+		fmt.Println("  | Use 'gogenda -profile=(name) (command)' to use another profile for one command")
+		fmt.Println("  | profile list - list the profiles, the one used by default is marked with a *")
+		fmt.Println("  | profile add - add a profile, with the credentials of the default one")
+		fmt.Println("          - (name)")
+		fmt.Println("  | profile use - use the profile by default")
+		fmt.Println("          - (name)")
+		fmt.Println("  | profile remove - remove the profile and all its files")
+		fmt.Println("          - (name)")
+	} else if strings.ToUpper(specificHelp) == "UNDO" {
+		fmt.Println(prefix + " undo - revert the last change done on your calendar (see 'history')")
+		fmt.Println("  | Deleted events are added again, moved or renamed events get their previous state back")
+		fmt.Println("  | If an event has been modified on the calendar since, you can overwrite, merge or abort")
+		fmt.Println("  - (nb of changes)")
 	}
 
 	if specificHelp != "" {
-		fmt.Println(" Param guide : (time) can be, case unsensitive, 'now', 'HH', 'HH:MM', 'HH:MM:SS'")
-		fmt.Println("             | (date) can be, case unsensitive, 'yesterday', 'today', 'tomorrow', 'YYYY-MM-DD', 'YYYY/MM/DD', 'MM-DD', 'MM/DD'")
+		fmt.Println(" Param guide : (time) can be, case unsensitive, 'now', 'HH', 'HH:MM', 'HH:MM:SS', '3pm', '9:30am'")
+		fmt.Println("             |   or relative : '+2h', '-15m', '+1h30m', 'in 2 hours', '15 minutes ago'")
+		fmt.Println("             | (date) can be, case unsensitive, 'yesterday', 'today', 'tomorrow', 'YYYY-MM-DD', 'YYYY/MM/DD', 'MM/DD', 'MM/DD/YYYY', '24th'")
+		fmt.Println("             |   (DD/MM and DD/MM/YYYY with the \"dateFormat\" DD/MM of your config.json file)")
+		fmt.Println("             |   'fri', 'next fri', 'last tue', 'this wed', 'in 2 days', '3 weeks ago', '+2d', '-1w', '2026-W42', '2026-W42-3'")
+		fmt.Println("             | (period) can be, case unsensitive, 'this|last|next week|month|quarter|year', 'wtd', 'mtd', 'ytd'")
+		fmt.Println("             |   'last 30d', 'last 2 weeks', '2026-Q3', '2026-09', '2026-W42', '2026', '(date)..(date)' or a single (date)")
 		fmt.Println("             | (category) is one of the one you declared in your config.json file, case unsensitive")
+		fmt.Println("             | (recurrence) can be, case unsensitive, '(every) weekday|day|week|month|year|mon,thu..', 'daily', 'weekly'..")
+		fmt.Println("             |   '(every) (n) days|weeks|months|years', followed by 'on mon,thu..', 'until (date)' or 'for (n) times'")
+		fmt.Println("             | Operations on an occurrence of a recurring event ask if they apply to it, the following ones or all of them")
 	}
 }
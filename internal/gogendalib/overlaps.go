@@ -0,0 +1,251 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lethenju/gogenda/internal/utilities"
+	"github.com/lethenju/gogenda/pkg/colors"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
+	"google.golang.org/api/calendar/v3"
+)
+
+// overlappingEvents returns the events that overlap the interval given in parameters
+func overlappingEvents(begin time.Time, end time.Time, events []*calendar.Event) (overlapping []*calendar.Event) {
+	for _, event := range events {
+		if event.Start.DateTime == "" {
+			// All day events dont take any time slot
+			continue
+		}
+		eventBegin, _ := api.GetEventStart(event, begin.Location())
+		eventEnd, _ := api.GetEventEnd(event, begin.Location())
+		if eventBegin.Before(end) && eventEnd.After(begin) {
+			overlapping = append(overlapping, event)
+		}
+	}
+	return overlapping
+}
+
+// nextFreeSlot returns the first time, from begin, where an event of the given duration overlaps none of the events
+func nextFreeSlot(begin time.Time, duration time.Duration, events []*calendar.Event) time.Time {
+	for {
+		overlapping := overlappingEvents(begin, begin.Add(duration), events)
+		if len(overlapping) == 0 {
+			return begin
+		}
+		// Try again after the overlapping events
+		for _, event := range overlapping {
+			eventEnd, _ := api.GetEventEnd(event, begin.Location())
+			if eventEnd.After(begin) {
+				begin = eventEnd
+			}
+		}
+	}
+}
+
+// trimOverlappingEvent shortens the event so that it doesnt overlap the interval given in parameters anymore.
+// An event covering the whole interval is split in two, an event inside the interval is deleted.
+// Only the times of the event are changed, the end of a split event is a copy of it
+func trimOverlappingEvent(ctx context.Context, event *calendar.Event, begin time.Time, end time.Time, srv *calendar.Service) (err error) {
+	eventBegin, _ := api.GetEventStart(event, begin.Location())
+	eventEnd, _ := api.GetEventEnd(event, begin.Location())
+
+	switch {
+	case !eventBegin.Before(begin) && !eventEnd.After(end):
+		// Inside the interval
+		return api.DeleteActivityFromID(ctx, event.Id, srv)
+	case eventBegin.Before(begin) && eventEnd.After(end):
+		// Covers the interval : keep the beginning, and add the end after the interval
+		_, err = api.CopyActivityBetween(ctx, event, end, eventEnd, srv)
+		if err != nil {
+			return err
+		}
+		return ResolveEditConflict(ctx, api.ResizeActivity(ctx, event, eventBegin, begin, srv), srv)
+	case eventBegin.Before(begin):
+		// Ends in the interval
+		return ResolveEditConflict(ctx, api.ResizeActivity(ctx, event, eventBegin, begin, srv), srv)
+	default:
+		// Starts in the interval
+		return ResolveEditConflict(ctx, api.ResizeActivity(ctx, event, end, eventEnd, srv), srv)
+	}
+}
+
+// overlapTrim is an event to trim out of the slot of time an event is added or moved to
+type overlapTrim struct {
+	event *calendar.Event
+	slot  interval
+}
+
+// trimOverlappingEvents trims the events out of their slots, see trimOverlappingEvent.
+// It is done once the event has been added or moved, so that nothing is trimmed if that fails
+func trimOverlappingEvents(ctx context.Context, trims []overlapTrim, srv *calendar.Service) (err error) {
+	for _, trim := range trims {
+		err = trimOverlappingEvent(ctx, trim.event, trim.slot.begin, trim.slot.end, srv)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// trimsOf returns the trims of the events out of the slot given in parameters
+func trimsOf(events []*calendar.Event, slot interval) (trims []overlapTrim) {
+	for _, event := range events {
+		trims = append(trims, overlapTrim{event: event, slot: slot})
+	}
+	return trims
+}
+
+// displayOverlaps lists the events that overlap the slots of the trims
+func displayOverlaps(trims []overlapTrim) {
+	colors.DisplayError("This overlaps " + describeNbEvents(len(trims)) + " :")
+	for _, trim := range trims {
+		colors.DisplayInfo(" " + describeEvent(trim.event, trim.slot.begin.Location()))
+	}
+}
+
+// checkOverlaps checks the interval where an event is about to be added or moved against the existing events.
+// If it overlaps some, they are listed and the user can trim them, shift the event to the next free slot,
+// proceed anyway or abort. ignoreID is the id of the event being moved, if any.
+// Returns the interval to use, the events to trim out of it with trimOverlappingEvents once the operation
+// is done, and false if the operation has to be aborted
+func checkOverlaps(ctx context.Context, begin time.Time, end time.Time, ignoreID string, srv *calendar.Service) (time.Time, time.Time, []overlapTrim, bool, error) {
+	// Get the events around, and after for the next free slot
+	cals, err := api.GetActivitiesBetweenDates(ctx, begin.AddDate(0, 0, -1).Format(time.RFC3339), end.AddDate(0, 0, 7).Format(time.RFC3339), srv)
+	if err != nil {
+		return begin, end, nil, false, err
+	}
+	var events []*calendar.Event
+	for _, event := range cals.Items {
+		if event.Id != ignoreID {
+			events = append(events, event)
+		}
+	}
+	trims := trimsOf(overlappingEvents(begin, end, events), interval{begin: begin, end: end})
+	if len(trims) == 0 {
+		return begin, end, nil, true, nil
+	}
+
+	displayOverlaps(trims)
+	for {
+		answer := strings.ToLower(utilities.InputFromUser("(t)rim them, (s)hift to the next free slot, (p)roceed anyway or (a)bort"))
+		switch answer {
+		case "t", "trim":
+			colors.DisplayInfo("They will be trimmed once the operation is done")
+			return begin, end, trims, true, nil
+		case "s", "shift":
+			freeBegin := nextFreeSlot(begin, end.Sub(begin), events)
+			colors.DisplayOk("Next free slot starts at " + utilities.FormatDateTime(freeBegin))
+			return freeBegin, freeBegin.Add(end.Sub(begin)), nil, true, nil
+		case "p", "proceed":
+			return begin, end, nil, true, nil
+		case "a", "abort":
+			colors.DisplayInfo("Aborting..")
+			return begin, end, nil, false, nil
+		}
+	}
+}
+
+// seriesCheckWeeks is the number of weeks of occurrences checked when a whole series is moved
+const seriesCheckWeeks = 4
+
+// checkSeriesOverlaps checks the occurrences of a series about to be moved by the offset given in parameters
+// against the existing events, like checkOverlaps. Only the occurrences of the next weeks are checked,
+// from the occurrence given for the following ones. The user can trim the events overlapping, proceed anyway or abort.
+// Returns the events to trim with trimOverlappingEvents once the series is moved, and false if the move has to be aborted
+func checkSeriesOverlaps(ctx context.Context, occurrence utilities.EventStored, scope string, occurrenceStart time.Time, offset time.Duration, srv *calendar.Service) ([]overlapTrim, bool, error) {
+	from := time.Now().In(occurrenceStart.Location())
+	if scope == api.ScopeFollowing || occurrenceStart.After(from) {
+		from = occurrenceStart
+	}
+	to := from.AddDate(0, 0, 7*seriesCheckWeeks)
+	margin := offset
+	if margin < 0 {
+		margin = -margin
+	}
+	cals, err := api.GetActivitiesBetweenDates(ctx, from.Add(-margin).AddDate(0, 0, -1).Format(time.RFC3339), to.Add(margin).AddDate(0, 0, 1).Format(time.RFC3339), srv)
+	if err != nil {
+		return nil, false, err
+	}
+	// The slots the moved occurrences go to, and the events that stay where they are
+	var slots []interval
+	var others []*calendar.Event
+	for _, event := range cals.Items {
+		slot, ok := eventInterval(event, occurrenceStart.Location())
+		if event.RecurringEventId == occurrence.RecurringEventID && !slot.begin.Before(from) && slot.begin.Before(to) {
+			if ok {
+				slots = append(slots, interval{begin: slot.begin.Add(offset), end: slot.end.Add(offset)})
+			}
+			continue
+		}
+		others = append(others, event)
+	}
+	var trims []overlapTrim
+	found := make(map[*calendar.Event]bool)
+	for _, slot := range slots {
+		for _, event := range overlappingEvents(slot.begin, slot.end, others) {
+			if !found[event] {
+				found[event] = true
+				trims = append(trims, overlapTrim{event: event, slot: slot})
+			}
+		}
+	}
+	if len(trims) == 0 {
+		return nil, true, nil
+	}
+
+	colors.DisplayInfo("In the next " + strconv.Itoa(seriesCheckWeeks) + " weeks of occurrences :")
+	displayOverlaps(trims)
+	for {
+		answer := strings.ToLower(utilities.InputFromUser("(t)rim them, (p)roceed anyway or (a)bort"))
+		switch answer {
+		case "t", "trim":
+			colors.DisplayInfo("They will be trimmed once the series is moved")
+			return trims, true, nil
+		case "p", "proceed":
+			return nil, true, nil
+		case "a", "abort":
+			colors.DisplayInfo("Aborting..")
+			return nil, false, nil
+		}
+	}
+}
+
+// describeNbEvents gives a number of events, to be displayed
+func describeNbEvents(nb int) string {
+	if nb == 1 {
+		return "1 event"
+	}
+	return strconv.Itoa(nb) + " events"
+}
@@ -30,6 +30,7 @@ SOFTWARE.
 package gogendalib
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
@@ -43,14 +44,16 @@ import (
 	"google.golang.org/api/calendar/v3"
 )
 
-func planCommand(command Command, srv *calendar.Service) (err error) {
+func planCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
+	// The dates are read and shown in the time zone of the command
+	location := utilities.Location(ctx)
 
 	// command[1] == action
-	// action could be SHOW, MOVE, DELETE, RENAME
+	// action could be SHOW, MOVE, DELETE, RENAME, COPY, EDIT-DAY
 
 	// Small helper function to check if the string is a possible action
 	containActionFunc := func(str string) bool {
-		actions := [5]string{"SHOW", "MOVE", "DELETE", "RENAME", "COPY"}
+		actions := [6]string{"SHOW", "MOVE", "DELETE", "RENAME", "COPY", "EDIT-DAY"}
 		for _, a := range actions {
 			if a == str {
 				return true
@@ -73,28 +76,14 @@ func planCommand(command Command, srv *calendar.Service) (err error) {
 		// init the plan structure
 		var planBuffer utilities.Plan
 
-		// Get plan of all day
-		begin := time.Now()
-		begin = time.Date(begin.Year(), begin.Month(), begin.Day(), 0, 0, 0, 0, time.Local)
-		if len(command) > 1 {
-			begin, err = utilities.DateParser(command[1])
-			if err != nil {
-				return err
-			}
-
-		}
-
-		nbDays := 1
-		if len(command) > 2 {
-			// Number of days to do
-			nbDays, err = strconv.Atoi(command[2])
-			if err != nil {
-				return errors.New("Wrong argument '" + command[2] + "', should be a number")
-			}
+		// Get plan of the period, all day by default
+		period, err := utilities.PeriodParser(command[1:], location)
+		if err != nil {
+			return err
 		}
-		end := begin.Add(time.Duration(24*nbDays) * time.Hour)
+		begin, end := period.Begin, period.End
 
-		cals, err := api.GetActivitiesBetweenDates(begin.Format(time.RFC3339), end.Format(time.RFC3339), srv)
+		cals, err := api.GetActivitiesBetweenDates(ctx, begin.Format(time.RFC3339), end.Format(time.RFC3339), srv)
 		if cals == nil {
 			colors.DisplayError("Error")
 			return err
@@ -103,15 +92,14 @@ func planCommand(command Command, srv *calendar.Service) (err error) {
 
 		var lastevent time.Time
 		if len(events) > 0 {
-			lastevent = time.Now()
+			lastevent = time.Now().In(location)
 		} else {
 			colors.DisplayOk("No events found")
 		}
 		for i, event := range events {
-			beginTime, _ := time.Parse(time.RFC3339, event.Start.DateTime)
-			endTime, _ := time.Parse(time.RFC3339, event.End.DateTime)
+			beginTime, _ := api.GetEventStart(event, location)
 			if beginTime.Day() != lastevent.Day() {
-				colors.DisplayInfoHeading(" Events of " + beginTime.Format("01/02"))
+				colors.DisplayInfoHeading(" Events of " + utilities.FormatShortDate(beginTime))
 			}
 			color, _ := api.GetColorNameFromColorID(event.ColorId)
 			category := configuration.GetNameFromColor(color)
@@ -120,19 +108,27 @@ func planCommand(command Command, srv *calendar.Service) (err error) {
 			}
 			category += "]"
 			category = fmt.Sprintf("[%-6s", category)
-			colors.DisplayOk("[" + strconv.Itoa(i) + "] [ " + beginTime.Format("15:04") + " -> " + endTime.Format("15:04") + " ] " + category + " : " + event.Summary)
+			kind := ""
+			if api.GetActivityKind(event) == api.KindPlanned {
+				kind = " (planned)"
+			}
+			colors.DisplayOk("[" + strconv.Itoa(i) + "] [ " + describeEventTime(event, false, location) + " ] " + category + " : " + event.Summary + kind)
 			lastevent = beginTime
 
 			// fill our data
 			var eventStored utilities.EventStored
 			eventStored.Name = event.Summary
 			eventStored.CalendarID = event.Id
+			eventStored.RecurringEventID = event.RecurringEventId
 			planBuffer.Events = append(planBuffer.Events, eventStored)
 		}
 		// store our data
 		utilities.StorePlan(&planBuffer)
 		return err
 	}
+	if action == "EDIT-DAY" {
+		return planEditDayCommand(ctx, command, srv)
+	}
 	// Load the plan data
 	planBuffer, err := utilities.LoadPlan()
 
@@ -149,31 +145,37 @@ func planCommand(command Command, srv *calendar.Service) (err error) {
 		return errors.New("Id should be a number, you gave :" + command[1])
 	}
 
+	if index < 0 || index >= len(planBuffer.Events) {
+		return errors.New("Id " + command[1] + " is not in the last 'plan show'")
+	}
+	event := planBuffer.Events[index]
+
 	switch action {
 	case "MOVE":
 		// grab the old date and time
-		date, err := api.GetStartDateForEventID(planBuffer.Events[index].CalendarID, srv)
+		date, err := api.GetStartDateForEventID(ctx, planBuffer.Events[index].CalendarID, location, srv)
 		if err != nil {
 			return err
 		}
+		oldDate := date
 		var t time.Time
 		// parse date and time
 		if len(command) == 3 || len(command) == 4 {
 			// MOVE ID date
 			// We need to change the date but not the time
-			dateParsed, err := utilities.DateParser(command[2])
-			date = time.Date(dateParsed.Year(), dateParsed.Month(), dateParsed.Day(), date.Hour(), date.Minute(), date.Second(), 0, time.Local)
+			dateParsed, err := utilities.DateParser(command[2], location)
+			date = time.Date(dateParsed.Year(), dateParsed.Month(), dateParsed.Day(), date.Hour(), date.Minute(), date.Second(), 0, location)
 			if len(command) == 4 {
 				// MOVE ID date time
-				t, err = utilities.TimeParser(command[3])
+				t, err = utilities.TimeParser(command[3], location)
 			}
 			if err != nil {
 				// MOVE ID time
-				t, err = utilities.TimeParser(command[2])
+				t, err = utilities.TimeParser(command[2], location)
 				if len(command) == 4 {
 					// MOVE ID time date
-					dateParsed, err = utilities.DateParser(command[3])
-					date = time.Date(dateParsed.Year(), dateParsed.Month(), dateParsed.Day(), date.Hour(), date.Minute(), date.Second(), 0, time.Local)
+					dateParsed, err = utilities.DateParser(command[3], location)
+					date = time.Date(dateParsed.Year(), dateParsed.Month(), dateParsed.Day(), date.Hour(), date.Minute(), date.Second(), 0, location)
 
 				}
 				if err != nil {
@@ -183,20 +185,48 @@ func planCommand(command Command, srv *calendar.Service) (err error) {
 			}
 		}
 		if !t.IsZero() {
-			date = time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+			date = time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, location)
+		}
+		endDate, err := api.GetEndDateForEventID(ctx, event.CalendarID, location, srv)
+		if err != nil {
+			return err
+		}
+		scope := askRecurrenceScope(event)
+		// The whole series is checked when it is moved with the occurrence
+		var toTrim []overlapTrim
+		var isOkay bool
+		if scope == api.ScopeThis {
+			date, _, toTrim, isOkay, err = checkOverlaps(ctx, date, date.Add(endDate.Sub(oldDate)), event.CalendarID, srv)
+		} else {
+			toTrim, isOkay, err = checkSeriesOverlaps(ctx, event, scope, oldDate, date.Sub(oldDate), srv)
+		}
+		if err != nil || !isOkay {
+			return err
 		}
 
-		colors.DisplayOk("Moving element nb " + strconv.Itoa(index) + " : " + planBuffer.Events[index].Name + " to date and time " + date.Format(time.UnixDate))
-		isOkay := utilities.AskOkFromUser("Are you okay with that operation ?")
+		colors.DisplayOk("Moving element nb " + strconv.Itoa(index) + " : " + planBuffer.Events[index].Name + describeScope(scope) + " to date and time " + date.Format(time.UnixDate))
+		isOkay = utilities.AskOkFromUser("Are you okay with that operation ?")
 		if !isOkay {
 			colors.DisplayInfo("Aborting..")
 			return nil
 		}
-		err = api.MoveActivityFromID(planBuffer.Events[index].CalendarID, date, srv)
-		return err
+		eventID, err := getScopeTarget(ctx, event, scope, srv)
+		if err != nil {
+			return err
+		}
+		// A series is moved by the same offset as the occurrence
+		start, err := api.GetStartDateForEventID(ctx, eventID, location, srv)
+		if err != nil {
+			return err
+		}
+		err = ResolveEditConflict(ctx, api.MoveActivityFromID(ctx, eventID, start.Add(date.Sub(oldDate)), srv), srv)
+		if err != nil {
+			return err
+		}
+		return trimOverlappingEvents(ctx, toTrim, srv)
 	case "COPY":
 		// grab the old date and time
-		date, err := api.GetStartDateForEventID(planBuffer.Events[index].CalendarID, srv)
+		date, err := api.GetStartDateForEventID(ctx, planBuffer.Events[index].CalendarID, location, srv)
 		if err != nil {
 			return err
 		}
@@ -205,19 +235,19 @@ func planCommand(command Command, srv *calendar.Service) (err error) {
 		if len(command) == 3 || len(command) == 4 {
 			// MOVE ID date
 			// We need to change the date but not the time
-			dateParsed, err := utilities.DateParser(command[2])
-			date = time.Date(dateParsed.Year(), dateParsed.Month(), dateParsed.Day(), date.Hour(), date.Minute(), date.Second(), 0, time.Local)
+			dateParsed, err := utilities.DateParser(command[2], location)
+			date = time.Date(dateParsed.Year(), dateParsed.Month(), dateParsed.Day(), date.Hour(), date.Minute(), date.Second(), 0, location)
 			if len(command) == 4 {
 				// MOVE ID date time
-				t, err = utilities.TimeParser(command[3])
+				t, err = utilities.TimeParser(command[3], location)
 			}
 			if err != nil {
 				// MOVE ID time
-				t, err = utilities.TimeParser(command[2])
+				t, err = utilities.TimeParser(command[2], location)
 				if len(command) == 4 {
 					// MOVE ID time date
-					dateParsed, err = utilities.DateParser(command[3])
-					date = time.Date(dateParsed.Year(), dateParsed.Month(), dateParsed.Day(), date.Hour(), date.Minute(), date.Second(), 0, time.Local)
+					dateParsed, err = utilities.DateParser(command[3], location)
+					date = time.Date(dateParsed.Year(), dateParsed.Month(), dateParsed.Day(), date.Hour(), date.Minute(), date.Second(), 0, location)
 
 				}
 				if err != nil {
@@ -227,25 +257,48 @@ func planCommand(command Command, srv *calendar.Service) (err error) {
 			}
 		}
 		if !t.IsZero() {
-			date = time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+			date = time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, location)
+		}
+		startDate, err := api.GetStartDateForEventID(ctx, event.CalendarID, location, srv)
+		if err != nil {
+			return err
+		}
+		endDate, err := api.GetEndDateForEventID(ctx, event.CalendarID, location, srv)
+		if err != nil {
+			return err
+		}
+		date, _, toTrim, isOkay, err := checkOverlaps(ctx, date, date.Add(endDate.Sub(startDate)), "", srv)
+		if err != nil || !isOkay {
+			return err
 		}
 
 		colors.DisplayOk("Copying element nb " + strconv.Itoa(index) + " : " + planBuffer.Events[index].Name + " to date and time " + date.Format(time.UnixDate))
-		isOkay := utilities.AskOkFromUser("Are you okay with that operation ?")
+		isOkay = utilities.AskOkFromUser("Are you okay with that operation ?")
 		if !isOkay {
 			colors.DisplayInfo("Aborting..")
 			return nil
 		}
-		err = api.CopyActivityFromID(planBuffer.Events[index].CalendarID, date, srv)
-		return err
+		err = api.CopyActivityFromID(ctx, planBuffer.Events[index].CalendarID, date, srv)
+		if err != nil {
+			return err
+		}
+		return trimOverlappingEvents(ctx, toTrim, srv)
 	case "DELETE":
-		colors.DisplayOk("Removing element nb " + strconv.Itoa(index) + " : " + planBuffer.Events[index].Name)
+		scope := askRecurrenceScope(event)
+		colors.DisplayOk("Removing element nb " + strconv.Itoa(index) + " : " + planBuffer.Events[index].Name + describeScope(scope))
 		isOkay := utilities.AskOkFromUser("Are you okay with that operation ?")
 		if !isOkay {
 			colors.DisplayInfo("Aborting..")
 			return nil
 		}
-		err = api.DeleteActivityFromID(planBuffer.Events[index].CalendarID, srv)
+		switch scope {
+		case api.ScopeFollowing:
+			err = ResolveEditConflict(ctx, api.TruncateRecurringActivity(ctx, event.CalendarID, srv), srv)
+		case api.ScopeAll:
+			err = api.DeleteActivityFromID(ctx, event.RecurringEventID, srv)
+		default:
+			err = api.DeleteActivityFromID(ctx, event.CalendarID, srv)
+		}
 		return err
 	case "RENAME":
 
@@ -256,20 +309,86 @@ func planCommand(command Command, srv *calendar.Service) (err error) {
 		}
 		name := strings.Join(command[2:], " ")
 		// Todo get the new name
-		colors.DisplayOk("Renaming element nb " + strconv.Itoa(index) + " : '" + planBuffer.Events[index].Name + "' to name '" + name + "'")
+		scope := askRecurrenceScope(event)
+		colors.DisplayOk("Renaming element nb " + strconv.Itoa(index) + " : '" + planBuffer.Events[index].Name + "'" + describeScope(scope) + " to name '" + name + "'")
 		isOkay := utilities.AskOkFromUser("Are you okay with that operation ?")
 		if !isOkay {
 			colors.DisplayInfo("Aborting..")
 			return nil
 		}
-		api.RenameActivityByID(planBuffer.Events[index].CalendarID, name, srv)
+		eventID, err := getScopeTarget(ctx, event, scope, srv)
+		if err != nil {
+			return err
+		}
+		err = ResolveEditConflict(ctx, api.RenameActivityByID(ctx, eventID, name, srv), srv)
 	}
 	return err
 }
 
+// askRecurrenceScope asks the user, if the event is an occurrence of a recurring event,
+// whether the operation applies to this occurrence, the following ones or the whole series
+func askRecurrenceScope(event utilities.EventStored) string {
+	if event.RecurringEventID == "" {
+		return api.ScopeThis
+	}
+	for {
+		answer := strings.ToLower(utilities.InputFromUser("'" + event.Name + "' is recurring. Apply to (t)his occurrence, the (f)ollowing ones or (a)ll of them"))
+		switch answer {
+		case "t", "this":
+			return api.ScopeThis
+		case "f", "following":
+			return api.ScopeFollowing
+		case "a", "all":
+			return api.ScopeAll
+		}
+	}
+}
+
+// describeScope gives the scope of an operation, to be displayed
+func describeScope(scope string) string {
+	switch scope {
+	case api.ScopeFollowing:
+		return " (and the following occurrences)"
+	case api.ScopeAll:
+		return " (and the whole series)"
+	}
+	return ""
+}
+
+// getScopeTarget returns the id of the event to modify for the scope of the operation.
+// For the following occurrences, the series is split so that they are a series of their own
+func getScopeTarget(ctx context.Context, event utilities.EventStored, scope string, srv *calendar.Service) (string, error) {
+	switch scope {
+	case api.ScopeFollowing:
+		return api.SplitRecurringActivity(ctx, event.CalendarID, srv)
+	case api.ScopeAll:
+		return event.RecurringEventID, nil
+	}
+	return event.CalendarID, nil
+}
+
 // Add an event sometime
 // If you want to add it now, you better use startCommand
-func addCommand(command Command, srv *calendar.Service) (err error) {
+func addCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
+	location := utilities.Location(ctx)
+
+	// A recurrence can be given at the end, like "every weekday" or "weekly on mon,thu until 2026-12-31"
+	var recurrence []string
+	for i := 1; i < len(command); i++ {
+		if utilities.IsRecurrenceStart(command[i]) {
+			rule, err := utilities.RecurrenceParser(command[i:], location)
+			if err == nil {
+				recurrence = []string{rule}
+				command = command[:i]
+				break
+			}
+		}
+	}
+
+	// A range of days, like "2026-12-24..2026-12-26 OFF holidays", adds an all day event
+	if len(command) > 1 && strings.Contains(command[1], "..") {
+		return addAllDayCommand(ctx, command, srv)
+	}
 
 	var date time.Time
 	var endDate time.Time
@@ -284,11 +403,11 @@ func addCommand(command Command, srv *calendar.Service) (err error) {
 		askAgain := true
 		for askAgain {
 			inputStr := utilities.InputFromUser("date of event")
-			t, err := utilities.DateParser(inputStr)
+			t, err := utilities.DateParser(inputStr, location)
 			if err != nil {
 				colors.DisplayError("Wrong formatting !")
 			} else {
-				*date = time.Date(t.Year(), t.Month(), t.Day(), date.Hour(), date.Minute(), date.Second(), 0, time.Local)
+				*date = time.Date(t.Year(), t.Month(), t.Day(), date.Hour(), date.Minute(), date.Second(), 0, location)
 				askAgain = false
 			}
 		}
@@ -297,11 +416,11 @@ func addCommand(command Command, srv *calendar.Service) (err error) {
 		askAgain := true
 		for askAgain {
 			inputStr := utilities.InputFromUser("begin time of event")
-			t, err := utilities.TimeParser(inputStr)
+			t, err := utilities.TimeParser(inputStr, location)
 			if err != nil {
 				colors.DisplayError("Wrong formatting !")
 			} else {
-				*date = time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+				*date = time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, location)
 				askAgain = false
 			}
 		}
@@ -310,11 +429,11 @@ func addCommand(command Command, srv *calendar.Service) (err error) {
 		askAgain := true
 		for askAgain {
 			inputStr := utilities.InputFromUser("end time of event")
-			t, err := utilities.TimeParser(inputStr)
+			t, err := utilities.TimeParser(inputStr, location)
 			if err != nil {
 				colors.DisplayError("Wrong formatting !")
 			} else {
-				*endDate = time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+				*endDate = time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, location)
 				if !endDate.After(date) {
 					colors.DisplayError("End time cannot be before start time !")
 				} else {
@@ -332,11 +451,11 @@ func addCommand(command Command, srv *calendar.Service) (err error) {
 
 	if len(command) == 2 {
 		// One argument given, we need to check which one is it : time, date or name
-		date, err = utilities.TimeParser(command[1])
+		date, err = utilities.TimeParser(command[1], location)
 		if err == nil { // we have our time
 			isTimeSet = true // So we set this flag on
 		} else {
-			date, err = utilities.DateParser(command[1])
+			date, err = utilities.DateParser(command[1], location)
 			if err == nil { // We have our date
 				isDateSet = true // So we set this flag on
 			} else { // Its a category
@@ -351,7 +470,7 @@ func addCommand(command Command, srv *calendar.Service) (err error) {
 		// date time
 		// date category
 
-		errTime, errDate := utilities.BuildDateFromTimeDate(command[1], command[2], &date)
+		errTime, errDate := utilities.BuildDateFromTimeDate(command[1], command[2], location, &date)
 		if errTime == nil && errDate == nil {
 			isTimeSet = true
 			isDateSet = true
@@ -361,7 +480,7 @@ func addCommand(command Command, srv *calendar.Service) (err error) {
 		} else {
 			// date time
 			// date category
-			errTime, errDate := utilities.BuildDateFromDateTime(command[1], command[2], &date)
+			errTime, errDate := utilities.BuildDateFromDateTime(command[1], command[2], location, &date)
 			if errTime == nil && errDate == nil {
 				isTimeSet = true
 				isDateSet = true
@@ -381,17 +500,17 @@ func addCommand(command Command, srv *calendar.Service) (err error) {
 		// date time category
 		// date category name
 
-		errTime, errDate := utilities.BuildDateFromTimeDate(command[1], command[2], &date)
+		errTime, errDate := utilities.BuildDateFromTimeDate(command[1], command[2], location, &date)
 		if errTime == nil && errDate == nil {
 			// time date category
 			isTimeSet = true
 			isDateSet = true
 
-			endDate, err = utilities.TimeParser(command[3])
+			endDate, err = utilities.TimeParser(command[3], location)
 			if err == nil { // we have the end hour. Still need to fix the day
-				endDate = time.Date(date.Year(), date.Month(), date.Day(), endDate.Hour(), endDate.Minute(), endDate.Second(), 0, time.Local)
+				endDate = time.Date(date.Year(), date.Month(), date.Day(), endDate.Hour(), endDate.Minute(), endDate.Second(), 0, location)
 				if date.After(endDate) { // like if the user wanted an event between 2 days (23:00 -> 01:00)
-					endDate.Add(24 * time.Hour) // move to the next day
+					endDate = endDate.AddDate(0, 0, 1) // move to the next day
 				}
 				isEndDateSet = true // So we set this flag on
 			} else {
@@ -407,17 +526,17 @@ func addCommand(command Command, srv *calendar.Service) (err error) {
 			// check if date time endDate
 			// check if date time category
 			// check if date category name
-			errTime, errDate := utilities.BuildDateFromDateTime(command[1], command[2], &date)
+			errTime, errDate := utilities.BuildDateFromDateTime(command[1], command[2], location, &date)
 			if errTime == nil && errDate == nil {
 				// date time category
 				isTimeSet = true
 				isDateSet = true
 				// Check if date time endDate
-				endDate, err = utilities.TimeParser(command[3])
+				endDate, err = utilities.TimeParser(command[3], location)
 				if err == nil { // we have the end hour. Still need to fix the day
-					endDate = time.Date(date.Year(), date.Month(), date.Day(), endDate.Hour(), endDate.Minute(), endDate.Second(), 0, time.Local)
+					endDate = time.Date(date.Year(), date.Month(), date.Day(), endDate.Hour(), endDate.Minute(), endDate.Second(), 0, location)
 					if date.After(endDate) { // like if the user wanted an event between 2 days (23:00 -> 01:00)
-						endDate.Add(24 * time.Hour) // move to the next day
+						endDate = endDate.AddDate(0, 0, 1) // move to the next day
 					}
 					isEndDateSet = true // So we set this flag on
 				} else {
@@ -469,11 +588,81 @@ func addCommand(command Command, srv *calendar.Service) (err error) {
 		askName(&name)
 	}
 
+	var isOkay bool
+	var toTrim []overlapTrim
+	date, endDate, toTrim, isOkay, err = checkOverlaps(ctx, date, endDate, "", srv)
+	if err != nil || !isOkay {
+		return err
+	}
+
+	color := configuration.GetColorFromName(category)
+	colors.DisplayOk("Adding event " + name + " of category " + category + " starting " + utilities.FormatDate(date) + " at " + utilities.FormatTime(date) + " until " + utilities.FormatTime(endDate))
+	if len(recurrence) > 0 {
+		colors.DisplayOk("Repeated with " + strings.Join(recurrence, " "))
+	}
+	_, err = api.InsertPlannedActivity(ctx, name, color, date, endDate, recurrence, srv)
+	if err != nil {
+		colors.DisplayError(err.Error())
+		return err
+	}
+	return trimOverlappingEvents(ctx, toTrim, srv)
+}
+
+// addAllDayCommand adds an event taking whole days, from a range of days like "2026-12-24..2026-12-26"
+func addAllDayCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
+	location := utilities.Location(ctx)
+	firstDay, lastDay, err := utilities.DateRangeParser(command[1], location)
+	if err != nil {
+		return errors.New("Wrong argument '" + command[1] + "', should be a range of days like 2026-12-24..2026-12-26")
+	}
+	category := ""
+	if len(command) > 2 {
+		category = command[2]
+	} else {
+		category = utilities.InputFromUser("category of event")
+	}
+	name := ""
+	if len(command) > 3 {
+		name = strings.Join(command[3:], " ")
+	} else {
+		name = utilities.InputFromUser("name of event")
+	}
+
 	color := configuration.GetColorFromName(category)
-	colors.DisplayOk("Adding event " + name + " of category " + category + " starting " + date.Format("2006-01-02") + " at " + date.Format("15:04") + " until " + endDate.Format("15:04"))
-	_, err = api.InsertActivity(name, color, date, endDate, srv)
+	colors.DisplayOk("Adding all day event " + name + " of category " + category + " from " + utilities.FormatDate(firstDay) + " to " + utilities.FormatDate(lastDay))
+	_, err = api.InsertAllDayActivity(ctx, name, color, firstDay, lastDay, srv)
 	if err != nil {
 		colors.DisplayError(err.Error())
 	}
 	return err
 }
+
+// describeEventTime describes when an event takes place, with its day if withDay is set.
+// All day events are shown with their days, and events over several days with the day of their start and end.
+// The times are the ones of the time zone given in parameters
+func describeEventTime(event *calendar.Event, withDay bool, location *time.Location) string {
+	beginTime, _ := api.GetEventStart(event, location)
+	endTime, _ := api.GetEventEnd(event, location)
+	formatDay := utilities.FormatShortDate
+	if withDay {
+		formatDay = utilities.FormatDate
+	}
+	if api.IsAllDay(event) {
+		// The end of all day events is the day after the last one
+		lastDay := endTime.AddDate(0, 0, -1)
+		if !lastDay.After(beginTime) {
+			if withDay {
+				return formatDay(beginTime) + " all day"
+			}
+			return "all day"
+		}
+		return formatDay(beginTime) + " -> " + formatDay(lastDay) + " all day"
+	}
+	if beginTime.YearDay() != endTime.YearDay() || beginTime.Year() != endTime.Year() {
+		return formatDay(beginTime) + " " + utilities.FormatTime(beginTime) + " -> " + formatDay(endTime) + " " + utilities.FormatTime(endTime)
+	}
+	if withDay {
+		return formatDay(beginTime) + " " + utilities.FormatTime(beginTime) + " -> " + utilities.FormatTime(endTime)
+	}
+	return utilities.FormatTime(beginTime) + " -> " + utilities.FormatTime(endTime)
+}
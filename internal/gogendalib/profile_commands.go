@@ -0,0 +1,106 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/lethenju/gogenda/internal/profiles"
+	"github.com/lethenju/gogenda/internal/utilities"
+	"github.com/lethenju/gogenda/pkg/colors"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
+)
+
+// profileCommand lists, adds, chooses or removes the profiles, each with its own account and configuration
+func profileCommand(command Command) (err error) {
+	action := "LIST"
+	if len(command) > 1 {
+		action = strings.ToUpper(command[1])
+	}
+	name := ""
+	if action != "LIST" {
+		if len(command) < 3 {
+			return errors.New("Missing the name of the profile")
+		}
+		name = command[2]
+	}
+	switch action {
+	case "LIST":
+		names, err := profiles.List()
+		if err != nil {
+			return err
+		}
+		current := profiles.GetCurrent()
+		for _, profile := range names {
+			dir, err := profiles.Dir(profile)
+			if err != nil {
+				return err
+			}
+			if profile == current {
+				colors.DisplayOk(" * " + profile + " (" + dir + ")")
+			} else {
+				colors.DisplayOk("   " + profile + " (" + dir + ")")
+			}
+		}
+	case "ADD":
+		err = profiles.Add(name)
+		if err != nil {
+			return err
+		}
+		dir, _ := profiles.Dir(name)
+		colors.DisplayOk("Profile '" + name + "' added in " + dir)
+		colors.DisplayInfo("Put its config.json there, then use it with 'gogenda -profile=" + name + "' or 'profile use " + name + "'")
+	case "USE":
+		err = profiles.SetCurrent(name)
+		if err != nil {
+			return err
+		}
+		colors.DisplayOk("Profile '" + name + "' will be used by default from the next launch")
+	case "REMOVE":
+		if !profiles.Exists(name) {
+			return errors.New("Profile '" + name + "' does not exist")
+		}
+		isOkay := utilities.AskOkFromUser("Remove the profile '" + name + "' with its token, configuration, templates and history ?")
+		if !isOkay {
+			colors.DisplayInfo("Aborting..")
+			return nil
+		}
+		// The token may be kept out of the folder
+		err = profiles.Remove(name, api.RemoveKeyringToken)
+		if err != nil {
+			return err
+		}
+		colors.DisplayOk("Profile '" + name + "' removed")
+	default:
+		return errors.New("Wrong argument '" + command[1] + "', should be list, add, use or remove")
+	}
+	return nil
+}
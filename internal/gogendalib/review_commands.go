@@ -0,0 +1,161 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/lethenju/gogenda/internal/utilities"
+	"github.com/lethenju/gogenda/pkg/colors"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
+	"google.golang.org/api/calendar/v3"
+)
+
+// A deviation is highlighted when it is over that part of the planned time
+const reviewTolerance = 0.15
+
+// formatDeviation formats the difference between the actual and the planned time, like "+15m0s"
+func formatDeviation(planned time.Duration, actual time.Duration) string {
+	deviation := actual - planned
+	if deviation >= 0 {
+		return "+" + deviation.String()
+	}
+	return deviation.String()
+}
+
+// displayComparison displays a line of the review, highlighted if the deviation is too big
+func displayComparison(str string, planned time.Duration, actual time.Duration) {
+	deviation := actual - planned
+	if deviation < 0 {
+		deviation = -deviation
+	}
+	line := str + " : planned " + planned.String() + ", done " + actual.String() + " (" + formatDeviation(planned, actual) + ")"
+	if float64(deviation) > reviewTolerance*float64(planned) {
+		colors.DisplayError(line)
+	} else {
+		colors.DisplayOk(line)
+	}
+}
+
+// loggedTimeIn returns the time logged in the category during the slot given in parameters
+func loggedTimeIn(slot interval, category string, logged []*calendar.Event) (total time.Duration) {
+	for _, event := range logged {
+		eventSlot, ok := eventInterval(event, slot.begin.Location())
+		if !ok || eventCategory(event) != category {
+			continue
+		}
+		begin, end := eventSlot.begin, eventSlot.end
+		if begin.Before(slot.begin) {
+			begin = slot.begin
+		}
+		if end.After(slot.end) {
+			end = slot.end
+		}
+		if end.After(begin) {
+			total += end.Sub(begin)
+		}
+	}
+	return total
+}
+
+// reviewCommand compares what was planned to what was actually logged, per category and per planned block
+func reviewCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
+	location := utilities.Location(ctx)
+	period, err := utilities.PeriodParser(command[1:], location)
+	if err != nil {
+		return err
+	}
+	begin, end := period.Begin, period.End
+	if now := time.Now().In(location); end.After(now) {
+		// What is not done yet cannot be reviewed
+		end = now
+	}
+	if !end.After(begin) {
+		return errors.New("nothing to review yet")
+	}
+
+	cals, err := api.GetActivitiesBetweenDates(ctx, begin.Format(time.RFC3339), end.Format(time.RFC3339), srv)
+	if err != nil {
+		return err
+	}
+	var planned, logged []*calendar.Event
+	plannedTotals := make(map[string]time.Duration)
+	loggedTotals := make(map[string]time.Duration)
+	for _, event := range cals.Items {
+		slot, ok := eventInterval(event, location)
+		if !ok {
+			continue
+		}
+		if api.GetActivityKind(event) == api.KindPlanned {
+			if slot.begin.After(end) {
+				continue
+			}
+			planned = append(planned, event)
+			plannedTotals[eventCategory(event)] += slot.duration()
+		} else {
+			logged = append(logged, event)
+			loggedTotals[eventCategory(event)] += slot.duration()
+		}
+	}
+	if len(planned) == 0 {
+		colors.DisplayOk("Nothing was planned, add planned blocks with 'add', 'template' or 'schedule'")
+		return nil
+	}
+
+	colors.DisplayInfoHeading("=== Per category ===")
+	var categories []string
+	for category := range plannedTotals {
+		categories = append(categories, category)
+	}
+	for category := range loggedTotals {
+		if _, ok := plannedTotals[category]; !ok {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		name := category
+		if name == "" {
+			name = "(no category)"
+		}
+		displayComparison(" "+name, plannedTotals[category], loggedTotals[category])
+	}
+
+	colors.DisplayInfoHeading("=== Per planned block ===")
+	for _, event := range planned {
+		slot, _ := eventInterval(event, location)
+		actual := loggedTimeIn(slot, eventCategory(event), logged)
+		displayComparison(" [ "+utilities.FormatShortDateTime(slot.begin)+" -> "+utilities.FormatTime(slot.end)+" ] ["+eventCategory(event)+"] "+event.Summary, slot.duration(), actual)
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cmdOptions "github.com/lethenju/gogenda/internal/cmd_options"
+	"github.com/lethenju/gogenda/internal/configuration"
+	"github.com/lethenju/gogenda/internal/utilities"
+	"github.com/lethenju/gogenda/pkg/colors"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
+	"google.golang.org/api/calendar/v3"
+)
+
+// Free slots shorter than that are not used by the scheduler
+const scheduleMinSlot = 15 * time.Minute
+
+// scheduleTask is a task to place in the free time
+type scheduleTask struct {
+	estimate time.Duration
+	category string
+	name     string
+	// priority, 1 is the highest
+	priority int
+	// deadline, zero if the task has none
+	deadline time.Time
+}
+
+// scheduleBlock is a block of time planned for a task
+type scheduleBlock struct {
+	task *scheduleTask
+	slot interval
+}
+
+// parseScheduleTask parses a task written as "estimate CATEGORY name [!priority] [@deadline]"
+// like "2h WORK refactor parser !1 @2026-10-24", the deadline being in the time zone given in parameters
+func parseScheduleTask(line string, location *time.Location) (task scheduleTask, err error) {
+	task.priority = 3
+	var nameWords []string
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return task, errors.New("a task should be 'estimate CATEGORY name [!priority] [@deadline]'")
+	}
+	task.estimate, err = time.ParseDuration(fields[0])
+	if err != nil || task.estimate <= 0 {
+		return task, errors.New("wrong estimate '" + fields[0] + "', should be like 45m or 1h30m")
+	}
+	task.category = fields[1]
+	for _, field := range fields[2:] {
+		switch {
+		case strings.HasPrefix(field, "!") && len(field) > 1:
+			task.priority, err = strconv.Atoi(field[1:])
+			if err != nil {
+				return task, errors.New("wrong priority '" + field + "', should be like !1")
+			}
+		case strings.HasPrefix(field, "@") && len(field) > 1:
+			deadline, err := utilities.DateParser(field[1:], location)
+			if err != nil {
+				return task, errors.New("wrong deadline '" + field + "', should be like @2026-10-24")
+			}
+			// The whole day of the deadline can be used
+			task.deadline = time.Date(deadline.Year(), deadline.Month(), deadline.Day(), 0, 0, 0, 0, location).AddDate(0, 0, 1)
+		default:
+			nameWords = append(nameWords, field)
+		}
+	}
+	task.name = strings.Join(nameWords, " ")
+	if task.name == "" {
+		return task, errors.New("the task has no name")
+	}
+	return task, nil
+}
+
+// readScheduleTasks reads the tasks, one per line, from a file or from the user if there is no file
+func readScheduleTasks(file string, location *time.Location) (tasks []scheduleTask, err error) {
+	if file == "" {
+		colors.DisplayInfo("One task per line : estimate CATEGORY name [!priority] [@deadline], like '2h WORK refactor parser !1 @friday'")
+		for {
+			line := utilities.InputFromUser("task (empty to finish)")
+			if strings.TrimSpace(line) == "" {
+				return tasks, nil
+			}
+			task, err := parseScheduleTask(line, location)
+			if err != nil {
+				colors.DisplayError(err.Error())
+				continue
+			}
+			tasks = append(tasks, task)
+		}
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(b)))
+	for nb := 1; scanner.Scan(); nb++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		task, err := parseScheduleTask(line, location)
+		if err != nil {
+			return nil, errors.New(file + " line " + strconv.Itoa(nb) + " : " + err.Error())
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// scheduleTasks places the tasks in the free slots, the most urgent first : by priority, then by deadline.
+// A block is never longer than maxBlock, and two blocks of the same task are never next to each other.
+// Returns the planned blocks, and the time that could not be placed for each task
+func scheduleTasks(tasks []scheduleTask, slots []interval, maxBlock time.Duration) (blocks []scheduleBlock, unplaced map[*scheduleTask]time.Duration) {
+	unplaced = make(map[*scheduleTask]time.Duration)
+	sort.SliceStable(tasks, func(p, q int) bool {
+		if tasks[p].priority != tasks[q].priority {
+			return tasks[p].priority < tasks[q].priority
+		}
+		if tasks[p].deadline.IsZero() || tasks[q].deadline.IsZero() {
+			return !tasks[p].deadline.IsZero()
+		}
+		return tasks[p].deadline.Before(tasks[q].deadline)
+	})
+	for t := range tasks {
+		task := &tasks[t]
+		remaining := task.estimate
+		for s := range slots {
+			if remaining <= 0 {
+				break
+			}
+			slot := &slots[s]
+			length := remaining
+			if length > maxBlock {
+				length = maxBlock
+			}
+			if length > slot.duration() {
+				length = slot.duration()
+			}
+			if !task.deadline.IsZero() && slot.begin.Add(length).After(task.deadline) {
+				length = task.deadline.Sub(slot.begin)
+			}
+			if length < scheduleMinSlot && length < remaining {
+				// Too small to be worth it
+				continue
+			}
+			blocks = append(blocks, scheduleBlock{task: task, slot: interval{begin: slot.begin, end: slot.begin.Add(length)}})
+			slot.begin = slot.begin.Add(length)
+			remaining -= length
+		}
+		if remaining > 0 {
+			unplaced[task] = remaining
+		}
+	}
+	sort.Slice(blocks, func(p, q int) bool {
+		return blocks[p].slot.begin.Before(blocks[q].slot.begin)
+	})
+	return blocks, unplaced
+}
+
+// scheduleCommand places a list of tasks in the free time of the next days, and adds them as planned events
+// schedule (file) --days 5 --max-block 2h
+func scheduleCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
+	command, options, err := cmdOptions.ExtractCommandOptions(command, []string{"days", "max-block"}, nil)
+	if err != nil {
+		return err
+	}
+	nbDays := 5
+	if options["days"] != "" {
+		nbDays, err = strconv.Atoi(options["days"])
+		if err != nil || nbDays < 1 {
+			return errors.New("Wrong argument '" + options["days"] + "', should be a positive number")
+		}
+	}
+	maxBlock := configuration.GetMaxBlock()
+	if options["max-block"] != "" {
+		maxBlock, err = time.ParseDuration(options["max-block"])
+		if err != nil || maxBlock < scheduleMinSlot {
+			return errors.New("Wrong duration '" + options["max-block"] + "', should be like 1h30m")
+		}
+	}
+	file := ""
+	if len(command) > 1 {
+		file = command[1]
+	}
+	location := utilities.Location(ctx)
+	tasks, err := readScheduleTasks(file, location)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		colors.DisplayOk("No tasks to schedule")
+		return nil
+	}
+
+	now := time.Now().In(location)
+	begin := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	end := begin.AddDate(0, 0, nbDays)
+	cals, err := api.GetActivitiesBetweenDates(ctx, begin.Format(time.RFC3339), end.Format(time.RFC3339), srv)
+	if err != nil {
+		return err
+	}
+	// Free slots in the working hours, from now on
+	var slots []interval
+	for day := begin; day.Before(end); day = day.AddDate(0, 0, 1) {
+		slot, ok := workingInterval(day)
+		if !ok {
+			continue
+		}
+		if slot.begin.Before(now) {
+			slot.begin = now.Truncate(time.Minute)
+		}
+		if slot.end.After(slot.begin) {
+			slots = append(slots, findGaps(slot, cals.Items, scheduleMinSlot)...)
+		}
+	}
+
+	blocks, unplaced := scheduleTasks(tasks, slots, maxBlock)
+	var lastDay time.Time
+	for _, block := range blocks {
+		if block.slot.begin.Day() != lastDay.Day() || block.slot.begin.Month() != lastDay.Month() {
+			colors.DisplayInfoHeading(" Planned on " + utilities.FormatDate(block.slot.begin))
+			lastDay = block.slot.begin
+		}
+		colors.DisplayOk(" [ " + utilities.FormatTime(block.slot.begin) + " -> " + utilities.FormatTime(block.slot.end) + " ] [" + strings.ToUpper(block.task.category) + "] : " + block.task.name)
+	}
+	for t := range tasks {
+		if remaining, ok := unplaced[&tasks[t]]; ok {
+			colors.DisplayError("Could not fit " + remaining.String() + " of '" + tasks[t].name + "'")
+		}
+	}
+	if len(blocks) == 0 {
+		colors.DisplayOk("Nothing could be planned")
+		return nil
+	}
+	if !utilities.AskOkFromUser("Are you okay with adding those " + strconv.Itoa(len(blocks)) + " blocks ?") {
+		colors.DisplayInfo("Aborting..")
+		return nil
+	}
+	var items []api.BatchItem
+	for _, block := range blocks {
+		items = append(items, api.InsertPlannedItem(block.task.name, configuration.GetColorFromName(block.task.category), block.slot.begin, block.slot.end))
+	}
+	nbErrors, _ := sendBatch(ctx, items, srv)
+	nbAdded := len(items) - nbErrors
+	colors.DisplayOk("Successfully planned " + strconv.Itoa(nbAdded) + " blocks !")
+	return nil
+}
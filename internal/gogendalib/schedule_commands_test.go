@@ -0,0 +1,129 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleTasks(t *testing.T) {
+	type block struct {
+		name  string
+		begin time.Time
+		end   time.Time
+	}
+	morning := interval{at(0, 9, 0), at(0, 12, 0)}
+	afternoon := interval{at(0, 14, 0), at(0, 18, 0)}
+	tomorrow := interval{at(1, 9, 0), at(1, 12, 0)}
+	tests := []struct {
+		name     string
+		tasks    []scheduleTask
+		slots    []interval
+		want     []block
+		unplaced map[string]time.Duration
+	}{
+		{
+			name:  "one task",
+			tasks: []scheduleTask{{name: "a", estimate: time.Hour, priority: 3}},
+			slots: []interval{morning},
+			want:  []block{{"a", at(0, 9, 0), at(0, 10, 0)}},
+		},
+		{
+			name:  "priority first",
+			tasks: []scheduleTask{{name: "low", estimate: time.Hour, priority: 3}, {name: "high", estimate: time.Hour, priority: 1}},
+			slots: []interval{morning},
+			want:  []block{{"high", at(0, 9, 0), at(0, 10, 0)}, {"low", at(0, 10, 0), at(0, 11, 0)}},
+		},
+		{
+			name: "deadline first, then no deadline",
+			tasks: []scheduleTask{
+				{name: "none", estimate: time.Hour, priority: 3},
+				{name: "later", estimate: time.Hour, priority: 3, deadline: at(3, 0, 0)},
+				{name: "sooner", estimate: time.Hour, priority: 3, deadline: at(2, 0, 0)},
+			},
+			slots: []interval{morning},
+			want:  []block{{"sooner", at(0, 9, 0), at(0, 10, 0)}, {"later", at(0, 10, 0), at(0, 11, 0)}, {"none", at(0, 11, 0), at(0, 12, 0)}},
+		},
+		{
+			name:  "split in blocks no longer than the max block",
+			tasks: []scheduleTask{{name: "a", estimate: 5 * time.Hour, priority: 3}},
+			slots: []interval{morning, afternoon, tomorrow},
+			want:  []block{{"a", at(0, 9, 0), at(0, 11, 0)}, {"a", at(0, 14, 0), at(0, 16, 0)}, {"a", at(1, 9, 0), at(1, 10, 0)}},
+		},
+		{
+			name:     "not enough time",
+			tasks:    []scheduleTask{{name: "a", estimate: 8 * time.Hour, priority: 3}},
+			slots:    []interval{morning, afternoon},
+			want:     []block{{"a", at(0, 9, 0), at(0, 11, 0)}, {"a", at(0, 14, 0), at(0, 16, 0)}},
+			unplaced: map[string]time.Duration{"a": 4 * time.Hour},
+		},
+		{
+			name:     "deadline cuts the blocks",
+			tasks:    []scheduleTask{{name: "a", estimate: 3 * time.Hour, priority: 3, deadline: at(0, 10, 30)}},
+			slots:    []interval{morning, tomorrow},
+			want:     []block{{"a", at(0, 9, 0), at(0, 10, 30)}},
+			unplaced: map[string]time.Duration{"a": 90 * time.Minute},
+		},
+		{
+			name:  "slots too small are skipped",
+			tasks: []scheduleTask{{name: "a", estimate: time.Hour, priority: 3}},
+			slots: []interval{{at(0, 9, 0), at(0, 9, 10)}, morning},
+			want:  []block{{"a", at(0, 9, 0), at(0, 10, 0)}},
+		},
+		{
+			name:  "small remainders fill small slots",
+			tasks: []scheduleTask{{name: "a", estimate: 10 * time.Minute, priority: 3}},
+			slots: []interval{{at(0, 9, 0), at(0, 9, 10)}},
+			want:  []block{{"a", at(0, 9, 0), at(0, 9, 10)}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			blocks, unplaced := scheduleTasks(test.tasks, test.slots, 2*time.Hour)
+			if len(blocks) != len(test.want) {
+				t.Fatalf("got %d blocks, want %d", len(blocks), len(test.want))
+			}
+			for i, b := range blocks {
+				if b.task.name != test.want[i].name || !b.slot.begin.Equal(test.want[i].begin) || !b.slot.end.Equal(test.want[i].end) {
+					t.Errorf("block %d is %s %v, want %v", i, b.task.name, b.slot, test.want[i])
+				}
+			}
+			if len(unplaced) != len(test.unplaced) {
+				t.Fatalf("got %d tasks unplaced, want %d", len(unplaced), len(test.unplaced))
+			}
+			for task, remaining := range unplaced {
+				if test.unplaced[task.name] != remaining {
+					t.Errorf("%s has %v unplaced, want %v", task.name, remaining, test.unplaced[task.name])
+				}
+			}
+		})
+	}
+}
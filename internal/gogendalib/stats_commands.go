@@ -30,13 +30,15 @@ SOFTWARE.
 package gogendalib
 
 import (
-	"errors"
+	"context"
+	"fmt"
 	"sort"
-	"strconv"
+	"strings"
 	"time"
 
 	cmdOptions "github.com/lethenju/gogenda/internal/cmd_options"
 	"github.com/lethenju/gogenda/internal/configuration"
+	"github.com/lethenju/gogenda/internal/current_activity"
 	"github.com/lethenju/gogenda/internal/utilities"
 	"github.com/lethenju/gogenda/pkg/colors"
 	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
@@ -44,39 +46,32 @@ import (
 	"google.golang.org/api/calendar/v3"
 )
 
-func statsCommand(command Command, srv *calendar.Service) (err error) {
-	// Get plan of all day
-	begin := time.Now()
-	begin = time.Date(begin.Year(), begin.Month(), begin.Day(), 0, 0, 0, 0, time.Local)
-	if len(command) > 1 {
-		begin, err = utilities.DateParser(command[1])
-		if err != nil {
-			return err
-		}
-
+func statsCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
+	if len(command) > 1 && strings.ToUpper(command[1]) == "ESTIMATES" {
+		return statsEstimatesCommand(ctx, command[1:], srv)
 	}
-
-	nbDays := 1
-	if len(command) > 2 {
-		// Number of days to do
-		nbDays, err = strconv.Atoi(command[2])
-		if err != nil {
-			return errors.New("Wrong argument '" + command[2] + "', should be a number")
-		}
-	}
-	end := begin.Add(time.Duration(24*nbDays) * time.Hour)
-
-	events, err := api.GetActivitiesBetweenDates(
-		begin.Format(time.RFC3339),
-		end.Format(time.RFC3339), srv)
+	// Get plan of the period, all day by default
+	location := utilities.Location(ctx)
+	period, err := utilities.PeriodParser(command[1:], location)
 	if err != nil {
 		return err
 	}
-	items := events.Items
+	begin, end := period.Begin, period.End
 
-	if err != nil {
-		return err
+	// All day events are left out, unless they count as a number of hours per day in the configuration
+	allDayDuration := configuration.GetAllDayDuration()
+	var items []*calendar.Event
+	it := api.NewEventIterator(ctx, begin, end, srv)
+	defer it.Close()
+	for it.Next() {
+		if !api.IsAllDay(it.Event()) || allDayDuration > 0 {
+			items = append(items, it.Event())
+		}
+	}
+	if it.Err() != nil {
+		return it.Err()
 	}
+
 	// sort by category
 	sort.Slice(items, func(p, q int) bool {
 		return items[p].ColorId < items[q].ColorId
@@ -97,15 +92,133 @@ func statsCommand(command Command, srv *calendar.Service) (err error) {
 			colors.DisplayInfoHeading("=== " + category + " ===")
 
 		}
-		startTime, _ := time.Parse(time.RFC3339, item.Start.DateTime)
-		endTime, _ := time.Parse(time.RFC3339, item.End.DateTime)
+		startTime, _ := api.GetEventStart(item, location)
+		endTime, _ := api.GetEventEnd(item, location)
 		duration := endTime.Sub(startTime)
+		if api.IsAllDay(item) {
+			duration = time.Duration(allDayNbDays(startTime, endTime, begin, end)) * allDayDuration
+		}
 		total += duration
 		if !cmdOptions.IsOptionSet("compact") {
-			colors.DisplayOk(" [ " + startTime.Format("15:04") + " -> " + endTime.Format("15:04") + " ] " + duration.String() + " : " + item.Summary)
+			colors.DisplayOk(" [ " + describeEventTime(item, false, location) + " ] " + duration.String() + " : " + item.Summary)
 		}
 	}
 	colors.DisplayOk("      Total : " + total.String())
 
 	return nil
 }
+
+// allDayNbDays returns the number of days of an all day event that are in the period given in parameters
+func allDayNbDays(eventBegin time.Time, eventEnd time.Time, begin time.Time, end time.Time) int {
+	nbDays := 0
+	for day := eventBegin; day.Before(eventEnd); day = day.AddDate(0, 0, 1) {
+		if !day.Before(begin) && day.Before(end) {
+			nbDays++
+		}
+	}
+	return nbDays
+}
+
+// estimateAccuracy aggregates the estimates of several activities and the time they actually took
+type estimateAccuracy struct {
+	nb        int
+	estimated time.Duration
+	actual    time.Duration
+	// absError is the sum of the errors of each estimate, whatever their sign
+	absError time.Duration
+}
+
+// add adds an activity to the aggregate
+func (accuracy *estimateAccuracy) add(estimated time.Duration, actual time.Duration) {
+	accuracy.nb++
+	accuracy.estimated += estimated
+	accuracy.actual += actual
+	if actual > estimated {
+		accuracy.absError += actual - estimated
+	} else {
+		accuracy.absError += estimated - actual
+	}
+}
+
+// String describes the aggregate, like "3 tasks, estimated 6h0m0s, took 7h30m0s (+25%), 40m0s off on average"
+func (accuracy estimateAccuracy) String() string {
+	percent := 0.0
+	if accuracy.estimated > 0 {
+		percent = 100 * float64(accuracy.actual-accuracy.estimated) / float64(accuracy.estimated)
+	}
+	meanError := accuracy.absError / time.Duration(accuracy.nb)
+	return fmt.Sprintf("%d tasks, estimated %s, took %s (%+.0f%%), %s off on average",
+		accuracy.nb, accuracy.estimated, accuracy.actual, percent, meanError.Truncate(time.Second))
+}
+
+// statsEstimatesCommand shows how accurate the estimates given with 'start CATEGORY ~2h name' were,
+// per category and per recurring task name
+func statsEstimatesCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
+	location := utilities.Location(ctx)
+	period, err := utilities.PeriodParser(command[1:], location)
+	if err != nil {
+		return err
+	}
+	begin, end := period.Begin, period.End
+
+	events, err := api.GetActivitiesBetweenDates(ctx, begin.Format(time.RFC3339), end.Format(time.RFC3339), srv)
+	if err != nil {
+		return err
+	}
+	// The running activity only has a placeholder end, it is not done yet
+	runningID := ""
+	if currentActivity, err := current_activity.GetCurrentActivity(); err == nil {
+		runningID = currentActivity.Id
+	}
+	byCategory := make(map[string]*estimateAccuracy)
+	byName := make(map[string]*estimateAccuracy)
+	for _, item := range events.Items {
+		estimate, ok := api.GetActivityEstimate(item)
+		slot, isTimed := eventInterval(item, location)
+		if !ok || !isTimed || item.Id == runningID || slot.end.After(time.Now()) {
+			continue
+		}
+		category := eventCategory(item)
+		if category == "" {
+			category = "(none)"
+		}
+		if byCategory[category] == nil {
+			byCategory[category] = &estimateAccuracy{}
+		}
+		byCategory[category].add(estimate, slot.duration())
+		name := strings.ToLower(strings.TrimSpace(item.Summary))
+		if byName[name] == nil {
+			byName[name] = &estimateAccuracy{}
+		}
+		byName[name].add(estimate, slot.duration())
+	}
+	if len(byCategory) == 0 {
+		colors.DisplayOk("No estimated activities, give one with 'start CATEGORY ~2h name'")
+		return nil
+	}
+
+	colors.DisplayInfoHeading("=== Per category ===")
+	var categories []string
+	for category := range byCategory {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		colors.DisplayOk(" " + category + " : " + byCategory[category].String())
+	}
+
+	var names []string
+	for name, accuracy := range byName {
+		if accuracy.nb > 1 {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		colors.DisplayInfoHeading("=== Per recurring task ===")
+		sort.Strings(names)
+		for _, name := range names {
+			colors.DisplayOk(" " + name + " : " + byName[name].String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"github.com/lethenju/gogenda/internal/current_activity"
+	"github.com/lethenju/gogenda/internal/utilities"
+	"github.com/lethenju/gogenda/pkg/colors"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
+	"google.golang.org/api/calendar/v3"
+)
+
+// syncCommand sends the changes done offline to the calendar
+func syncCommand(ctx context.Context, srv *calendar.Service) (err error) {
+	if api.CountPendingMutations() == 0 {
+		colors.DisplayOk("Nothing to sync")
+		return nil
+	}
+	return sendPendingMutations(ctx, srv)
+}
+
+// sendPendingMutations sends the changes done offline, shows what happened to each one
+// and lets the user resolve the conflicts
+func sendPendingMutations(ctx context.Context, srv *calendar.Service) (err error) {
+	colors.DisplayInfo("Sending " + strconv.Itoa(api.CountPendingMutations()) + " changes done offline..")
+	results, ids, err := api.SyncOutbox(ctx, srv)
+	errIDs := replaceProvisionalIDs(ids)
+	if errIDs != nil {
+		colors.DisplayError("The stored plan could not be updated, show it again : " + errIDs.Error())
+	}
+	for _, result := range results {
+		description := result.Entry.Operation + " " + describeEvent(result.Entry.Event, utilities.Location(ctx))
+		switch result.Err.(type) {
+		case nil:
+			colors.DisplayOk(" Sent : " + description)
+		case *api.ConflictError:
+			errConflict := ResolveEditConflict(ctx, result.Err, srv)
+			if errConflict != nil {
+				colors.DisplayError(" Failed : " + description + " : " + errConflict.Error())
+			}
+		default:
+			colors.DisplayError(" Failed : " + description + " : " + result.Err.Error())
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if pending := api.CountPendingMutations(); pending > 0 {
+		colors.DisplayInfo("Still offline, " + strconv.Itoa(pending) + " changes are waiting to be sent")
+	}
+	return nil
+}
+
+// replaceProvisionalIDs gives their ids on the calendar to the events inserted offline
+// that are the current activity or in the stored plan
+func replaceProvisionalIDs(ids map[string]string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if activity, err := current_activity.GetCurrentActivity(); err == nil {
+		if id, ok := ids[activity.Id]; ok {
+			activity.Id = id
+		}
+	}
+	planBuffer, err := utilities.LoadPlan()
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	changed := false
+	for i, event := range planBuffer.Events {
+		if id, ok := ids[event.CalendarID]; ok {
+			planBuffer.Events[i].CalendarID = id
+			changed = true
+		}
+		if id, ok := ids[event.RecurringEventID]; ok {
+			planBuffer.Events[i].RecurringEventID = id
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return utilities.StorePlan(&planBuffer)
+}
@@ -0,0 +1,88 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/lethenju/gogenda/internal/current_activity"
+	"github.com/lethenju/gogenda/internal/utilities"
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestReplaceProvisionalIDs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gogenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(oldDir string) { utilities.Dir = oldDir }(utilities.Dir)
+	utilities.Dir = dir
+	defer current_activity.SetCurrentActivity(nil)
+
+	// Nothing stored yet
+	if err := replaceProvisionalIDs(map[string]string{"local-1": "sent1"}); err != nil {
+		t.Errorf("without a stored plan : unexpected error %v", err)
+	}
+
+	current_activity.SetCurrentActivity(&calendar.Event{Id: "local-1", Summary: "Writing"})
+	err = utilities.StorePlan(&utilities.Plan{Events: []utilities.EventStored{
+		{Name: "Writing", CalendarID: "local-1"},
+		{Name: "Reading", CalendarID: "onCalendar"},
+		{Name: "Daily", CalendarID: "local-2_20200302", RecurringEventID: "local-2"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = replaceProvisionalIDs(map[string]string{"local-1": "sent1", "local-2": "sent2"})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	activity, err := current_activity.GetCurrentActivity()
+	if err != nil || activity.Id != "sent1" {
+		t.Errorf("the current activity is %v, %v, want the id sent1", activity, err)
+	}
+	plan, err := utilities.LoadPlan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"sent1", "onCalendar", "local-2_20200302"}
+	for i, event := range plan.Events {
+		if event.CalendarID != want[i] {
+			t.Errorf("the event %d of the plan has the id %s, want %s", i, event.CalendarID, want[i])
+		}
+	}
+	if plan.Events[2].RecurringEventID != "sent2" {
+		t.Errorf("the series of the last event of the plan is %s, want sent2", plan.Events[2].RecurringEventID)
+	}
+}
@@ -0,0 +1,290 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package gogendalib
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lethenju/gogenda/internal/configuration"
+	"github.com/lethenju/gogenda/internal/templates"
+	"github.com/lethenju/gogenda/internal/utilities"
+	"github.com/lethenju/gogenda/pkg/colors"
+	api "github.com/lethenju/gogenda/pkg/google_agenda_api"
+	"google.golang.org/api/calendar/v3"
+)
+
+// blockInterval returns the start and end time of a template block, applied to the day given in parameters
+func blockInterval(block templates.Block, day time.Time) (begin time.Time, end time.Time, err error) {
+	t, err := utilities.TimeParser(block.Start, day.Location())
+	if err != nil {
+		return begin, end, errors.New("wrong start time '" + block.Start + "' for '" + block.Name + "'")
+	}
+	begin = time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location())
+	t, err = utilities.TimeParser(block.End, day.Location())
+	if err != nil {
+		return begin, end, errors.New("wrong end time '" + block.End + "' for '" + block.Name + "'")
+	}
+	end = time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location())
+	if !end.After(begin) {
+		// The block ends the next day
+		end = end.AddDate(0, 0, 1)
+	}
+	return begin, end, nil
+}
+
+// templateCommand saves days of the calendar as templates, and applies them to other dates
+func templateCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
+	action := "LIST"
+	if len(command) > 1 {
+		action = strings.ToUpper(command[1])
+	}
+	switch action {
+	case "LIST":
+		names, err := templates.ListTemplates()
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			colors.DisplayOk("No templates yet, create one with 'template save'")
+		}
+		for _, name := range names {
+			template, err := templates.LoadTemplate(name)
+			if err != nil {
+				colors.DisplayError(" " + name + " : " + err.Error())
+				continue
+			}
+			colors.DisplayOk(" " + name + " : " + strconv.Itoa(template.Days) + " days, " + strconv.Itoa(len(template.Blocks)) + " blocks")
+		}
+		return nil
+	case "SHOW":
+		if len(command) < 3 {
+			return errors.New("not enough arguments : gogenda template show name")
+		}
+		template, err := templates.LoadTemplate(command[2])
+		if err != nil {
+			return err
+		}
+		lastDay := -1
+		for _, block := range template.Blocks {
+			if block.Day != lastDay {
+				colors.DisplayInfoHeading(" Day " + strconv.Itoa(block.Day+1))
+				lastDay = block.Day
+			}
+			colors.DisplayOk(" [ " + block.Start + " -> " + block.End + " ] [" + block.Category + "] : " + block.Name)
+		}
+		return nil
+	case "SAVE":
+		return templateSaveCommand(ctx, command[1:], srv)
+	case "APPLY":
+		return templateApplyCommand(ctx, command[1:], srv)
+	case "DELETE":
+		if len(command) < 3 {
+			return errors.New("not enough arguments : gogenda template delete name")
+		}
+		err = templates.DeleteTemplate(command[2])
+		if err != nil {
+			return err
+		}
+		colors.DisplayOk("Successfully deleted the template !")
+		return nil
+	}
+	return errors.New("unknown template action '" + command[1] + "'")
+}
+
+// templateSaveCommand saves the events of one or several days as a template
+// template save name (date) (nb of days)
+func templateSaveCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
+	if len(command) < 2 {
+		return errors.New("not enough arguments : gogenda template save name (date) (nb of days)")
+	}
+	name := command[1]
+	location := utilities.Location(ctx)
+	begin := time.Now().In(location)
+	if len(command) > 2 {
+		begin, err = utilities.DateParser(command[2], location)
+		if err != nil {
+			return err
+		}
+	}
+	begin = time.Date(begin.Year(), begin.Month(), begin.Day(), 0, 0, 0, 0, location)
+	nbDays := 1
+	if len(command) > 3 {
+		nbDays, err = strconv.Atoi(command[3])
+		if err != nil || nbDays < 1 {
+			return errors.New("Wrong argument '" + command[3] + "', should be a positive number")
+		}
+	}
+	end := begin.AddDate(0, 0, nbDays)
+
+	cals, err := api.GetActivitiesBetweenDates(ctx, begin.Format(time.RFC3339), end.Format(time.RFC3339), srv)
+	if err != nil {
+		return err
+	}
+	template := templates.Template{Days: nbDays}
+	for _, event := range cals.Items {
+		if event.Start.DateTime == "" {
+			continue
+		}
+		beginTime, _ := api.GetEventStart(event, location)
+		endTime, _ := api.GetEventEnd(event, location)
+		day := time.Date(beginTime.Year(), beginTime.Month(), beginTime.Day(), 0, 0, 0, 0, location)
+		if day.Before(begin) {
+			// Started the day before
+			continue
+		}
+		color, _ := api.GetColorNameFromColorID(event.ColorId)
+		category := configuration.GetNameFromColor(color)
+		if category == "default" {
+			category = ""
+		}
+		template.Blocks = append(template.Blocks, templates.Block{
+			Day:      int(day.Sub(begin).Hours()+12) / 24,
+			Start:    beginTime.Format("15:04"),
+			End:      endTime.Format("15:04"),
+			Category: category,
+			Name:     event.Summary,
+		})
+	}
+	if len(template.Blocks) == 0 {
+		return errors.New("no events to save")
+	}
+	err = templates.SaveTemplate(name, &template)
+	if err != nil {
+		return err
+	}
+	colors.DisplayOk("Successfully saved " + strconv.Itoa(len(template.Blocks)) + " blocks in the template '" + name + "' !")
+	return nil
+}
+
+// templateApplyCommand adds the blocks of a template on a range of days
+// Days that already have events conflicting with the blocks are skipped.
+// template apply name (date)..(date)
+func templateApplyCommand(ctx context.Context, command Command, srv *calendar.Service) (err error) {
+	if len(command) < 2 {
+		return errors.New("not enough arguments : gogenda template apply name (date)..(date)")
+	}
+	template, err := templates.LoadTemplate(command[1])
+	if err != nil {
+		return err
+	}
+	location := utilities.Location(ctx)
+	first := time.Now().In(location)
+	first = time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, location)
+	last := first.AddDate(0, 0, template.Days-1)
+	if len(command) > 2 {
+		first, last, err = utilities.DateRangeParser(command[2], location)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(command[2], "..") {
+			// A single date is the first day of the template
+			last = first.AddDate(0, 0, template.Days-1)
+		}
+	}
+
+	cals, err := api.GetActivitiesBetweenDates(ctx, first.Format(time.RFC3339), last.AddDate(0, 0, 2).Format(time.RFC3339), srv)
+	if err != nil {
+		return err
+	}
+
+	// Find the blocks to add, day by day
+	type dayPlan struct {
+		day      time.Time
+		blocks   []templates.Block
+		conflict string
+	}
+	var plans []dayPlan
+	nbBlocks := 0
+	for i, day := 0, first; !day.After(last); i, day = i+1, day.AddDate(0, 0, 1) {
+		plan := dayPlan{day: day}
+		for _, block := range template.Blocks {
+			if block.Day != i%template.Days {
+				continue
+			}
+			begin, end, err := blockInterval(block, day)
+			if err != nil {
+				return err
+			}
+			overlapping := overlappingEvents(begin, end, cals.Items)
+			if len(overlapping) > 0 && plan.conflict == "" {
+				plan.conflict = "'" + block.Name + "' conflicts with '" + overlapping[0].Summary + "'"
+			}
+			plan.blocks = append(plan.blocks, block)
+		}
+		if plan.conflict == "" {
+			nbBlocks += len(plan.blocks)
+		}
+		plans = append(plans, plan)
+	}
+
+	for _, plan := range plans {
+		if plan.conflict != "" {
+			colors.DisplayInfo(" " + utilities.FormatDate(plan.day) + " : skipped, " + plan.conflict)
+		} else {
+			colors.DisplayOk(" " + utilities.FormatDate(plan.day) + " : " + strconv.Itoa(len(plan.blocks)) + " blocks to add")
+		}
+	}
+	if nbBlocks == 0 {
+		colors.DisplayOk("Nothing to add")
+		return nil
+	}
+	isOkay := utilities.AskOkFromUser("Are you okay with adding those " + strconv.Itoa(nbBlocks) + " blocks ?")
+	if !isOkay {
+		colors.DisplayInfo("Aborting..")
+		return nil
+	}
+
+	var items []api.BatchItem
+	// The day of each item, to count the days where blocks have been added
+	var itemDays []int
+	for i, plan := range plans {
+		if plan.conflict != "" {
+			continue
+		}
+		for _, block := range plan.blocks {
+			begin, end, _ := blockInterval(block, plan.day)
+			items = append(items, api.InsertPlannedItem(block.Name, configuration.GetColorFromName(block.Category), begin, end))
+			itemDays = append(itemDays, i)
+		}
+	}
+	nbErrors, done := sendBatch(ctx, items, srv)
+	daysAdded := make(map[int]bool)
+	for i, isDone := range done {
+		if isDone {
+			daysAdded[itemDays[i]] = true
+		}
+	}
+	colors.DisplayOk("Added " + strconv.Itoa(len(items)-nbErrors) + " blocks on " + strconv.Itoa(len(daysAdded)) + " days !")
+	return nil
+}
@@ -0,0 +1,194 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package profiles
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultName is the profile using the files directly in ~/.gogenda, as before profiles existed
+const DefaultName = "default"
+
+// rootDir returns the folder of gogenda, holding the default profile and the other ones
+func rootDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.New("The folder of gogenda cannot be found : " + err.Error())
+	}
+	return home + "/.gogenda", nil
+}
+
+// currentPath returns the path of the file holding the name of the profile used by default
+func currentPath() (string, error) {
+	root, err := rootDir()
+	return root + "/profile", err
+}
+
+// checkName checks that the name of the profile can be used as a folder name
+func checkName(name string) error {
+	if name == "" || strings.ContainsAny(name, "/\\.") {
+		return errors.New("Wrong profile name '" + name + "'")
+	}
+	return nil
+}
+
+// Dir returns the folder holding the credentials, token, configuration, templates and state of a profile
+func Dir(name string) (string, error) {
+	root, err := rootDir()
+	if err != nil || name == DefaultName {
+		return root, err
+	}
+	return root + "/profiles/" + name, nil
+}
+
+// Exists checks if the profile has been added
+func Exists(name string) bool {
+	if name == DefaultName {
+		return true
+	}
+	if checkName(name) != nil {
+		return false
+	}
+	dir, err := Dir(name)
+	if err != nil {
+		return false
+	}
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir()
+}
+
+// GetCurrent returns the profile used when none is given with -profile, the default one if none has been chosen
+func GetCurrent() string {
+	path, err := currentPath()
+	if err != nil {
+		return DefaultName
+	}
+	b, err := ioutil.ReadFile(path)
+	name := strings.TrimSpace(string(b))
+	if err != nil || !Exists(name) {
+		return DefaultName
+	}
+	return name
+}
+
+// SetCurrent chooses the profile used when none is given with -profile
+func SetCurrent(name string) error {
+	if !Exists(name) {
+		return errors.New("Profile '" + name + "' does not exist")
+	}
+	path, err := currentPath()
+	if err != nil {
+		return err
+	}
+	if name == DefaultName {
+		err = os.Remove(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(name+"\n"), 0600)
+}
+
+// List returns the names of the profiles, the default one first
+func List() (names []string, err error) {
+	root, err := rootDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := ioutil.ReadDir(root + "/profiles")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+	return append([]string{DefaultName}, names...), nil
+}
+
+// Add creates a profile. The credentials of the default profile are copied, as the same application
+// can access several accounts : the profile only needs to log in with its own
+func Add(name string) error {
+	if err := checkName(name); err != nil {
+		return err
+	}
+	if Exists(name) {
+		return errors.New("Profile '" + name + "' already exists")
+	}
+	dir, err := Dir(name)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+	defaultDir, _ := Dir(DefaultName)
+	credentials, err := ioutil.ReadFile(filepath.Join(defaultDir, "credentials.json"))
+	if err != nil {
+		// No credentials to copy, they will have to be put in the folder of the profile
+		return nil
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "credentials.json"), credentials, 0600)
+}
+
+// Remove deletes a profile and all its files. cleanup is given the folder of the profile to remove
+// what it keeps out of it, like its token. The default profile cannot be removed
+func Remove(name string, cleanup func(dir string) error) error {
+	if name == DefaultName {
+		return errors.New("The default profile cannot be removed")
+	}
+	if !Exists(name) {
+		return errors.New("Profile '" + name + "' does not exist")
+	}
+	if GetCurrent() == name {
+		err := SetCurrent(DefaultName)
+		if err != nil {
+			return err
+		}
+	}
+	dir, err := Dir(name)
+	if err != nil {
+		return err
+	}
+	err = cleanup(dir)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
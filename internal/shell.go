@@ -2,10 +2,12 @@ package gogenda
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/lethenju/gogenda/internal/current_activity"
 	"github.com/lethenju/gogenda/internal/gogendalib"
@@ -15,15 +17,29 @@ import (
 	"google.golang.org/api/calendar/v3"
 )
 
+// describeRemaining describes the time remaining before the estimate of the activity is reached
+func describeRemaining(activity *calendar.Event, estimate time.Duration) string {
+	startTime, err := api.GetEventStart(activity, time.Local)
+	if err != nil {
+		return ""
+	}
+	remaining := estimate - time.Since(startTime).Truncate(time.Minute)
+	if remaining < 0 {
+		return "(" + (-remaining).String() + " over)"
+	}
+	return "(" + remaining.String() + " left)"
+}
+
 //Shell : Gogenda can be called as a shell, to have a shell like environement for long periods of usage
 func Shell(srv *calendar.Service, version string) {
 	runningFlag := true
+	ctx := context.Background()
 
 	colors.DisplayInfoHeading("Welcome to GoGenda!")
 	colors.DisplayInfo("Version number : " + version)
 
 	// Asking the user if he's still doing the last event on google agenda
-	lastEvent, err := api.GetLastEvent(srv)
+	lastEvent, err := api.GetLastEvent(ctx, srv)
 	if err == nil && lastEvent.Id != "" {
 		fmt.Println("Last event : " + lastEvent.Summary)
 		if utilities.AskOkFromUser("Are you still doing that ?") {
@@ -56,6 +72,10 @@ func Shell(srv *calendar.Service, version string) {
 					colors.DisplayError("ERROR : " + err.Error())
 				}
 				colors.DisplayInfoNoNL(duration)
+				if estimate, ok := api.GetActivityEstimate(act); ok {
+					colors.DisplayInfoNoNL(" / ~" + estimate.String())
+					colors.DisplayOkNoNL(" " + describeRemaining(act, estimate))
+				}
 
 				fmt.Print(" ]")
 			}
@@ -71,7 +91,10 @@ func Shell(srv *calendar.Service, version string) {
 			fmt.Println("See you later !")
 			currentActivity, err := current_activity.GetCurrentActivity()
 			if err == nil {
-				api.StopActivity(currentActivity, srv)
+				err = gogendalib.ResolveEditConflict(ctx, api.StopActivity(ctx, currentActivity, srv), srv)
+				if err != nil && err != gogendalib.ErrAborted {
+					colors.DisplayError("ERROR : " + err.Error())
+				}
 			}
 			runningFlag = false
 			break
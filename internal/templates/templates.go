@@ -0,0 +1,140 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package templates
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Block is an event of a template
+type Block struct {
+	// Day is the day of the block, counted from the first day of the template (0)
+	Day int `json:"day"`
+	// Start is the start time of the block, "HH:MM"
+	Start string `json:"start"`
+	// End is the end time of the block, "HH:MM". The block ends the next day if it is before the start
+	End string `json:"end"`
+	// Category of the block, as declared in the configuration
+	Category string `json:"category"`
+	// Name of the block
+	Name string `json:"name"`
+}
+
+// Template is a day or a week (or any number of days) of events, that can be applied to other dates
+type Template struct {
+	// Days is the number of days the template lasts
+	Days int `json:"days"`
+	// Blocks are the events of the template
+	Blocks []Block `json:"blocks"`
+}
+
+// Dir is the folder holding the templates folder, the one of the profile
+var Dir string
+
+// templatesDir returns the folder where the templates are stored
+func templatesDir() string {
+	return Dir + "/templates/"
+}
+
+// checkName checks that the name of the template can be used as a file name
+func checkName(name string) error {
+	if name == "" || strings.ContainsAny(name, "/\\.") {
+		return errors.New("Wrong template name '" + name + "'")
+	}
+	return nil
+}
+
+// LoadTemplate loads the template of the name given in parameters
+func LoadTemplate(name string) (template Template, err error) {
+	if err = checkName(name); err != nil {
+		return template, err
+	}
+	f, err := os.Open(templatesDir() + name + ".json")
+	if os.IsNotExist(err) {
+		return template, errors.New("No template named '" + name + "'")
+	}
+	if err != nil {
+		return template, err
+	}
+	defer f.Close()
+	err = json.NewDecoder(f).Decode(&template)
+	if err == nil && template.Days < 1 {
+		template.Days = 1
+	}
+	return template, err
+}
+
+// SaveTemplate saves the template under the name given in parameters, replacing any template of that name
+func SaveTemplate(name string, template *Template) (err error) {
+	if err = checkName(name); err != nil {
+		return err
+	}
+	err = os.MkdirAll(templatesDir(), 0700)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(templatesDir() + name + ".json")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(template)
+}
+
+// DeleteTemplate deletes the template of the name given in parameters
+func DeleteTemplate(name string) (err error) {
+	if err = checkName(name); err != nil {
+		return err
+	}
+	return os.Remove(templatesDir() + name + ".json")
+}
+
+// ListTemplates returns the names of all the stored templates
+func ListTemplates() (names []string, err error) {
+	files, err := ioutil.ReadDir(templatesDir())
+	if os.IsNotExist(err) {
+		return names, nil
+	}
+	if err != nil {
+		return names, err
+	}
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".json") {
+			names = append(names, strings.TrimSuffix(file.Name(), ".json"))
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,103 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package utilities
+
+import (
+	"strconv"
+	"time"
+)
+
+// Date formats that can be configured
+const (
+	DateFormatISO      = "YYYY-MM-DD"
+	DateFormatMonthDay = "MM/DD"
+	DateFormatDayMonth = "DD/MM"
+)
+
+// DateFormat is the order the dates are written and shown in, one of the DateFormat constants
+var DateFormat = DateFormatISO
+
+// Clock12h is set when the times are shown with am/pm instead of 24 hours
+var Clock12h = false
+
+// IsDayFirst returns true when the day is written before the month, like in 24/12
+func IsDayFirst() bool {
+	return DateFormat == DateFormatDayMonth
+}
+
+// FormatDate shows the day of the date, with its year
+func FormatDate(date time.Time) string {
+	switch DateFormat {
+	case DateFormatMonthDay:
+		return date.Format("01/02/2006")
+	case DateFormatDayMonth:
+		return date.Format("02/01/2006")
+	}
+	return date.Format("2006-01-02")
+}
+
+// FormatShortDate shows the day of the date, without its year
+func FormatShortDate(date time.Time) string {
+	if IsDayFirst() {
+		return date.Format("02/01")
+	}
+	return date.Format("01/02")
+}
+
+// FormatTime shows the time of the date, like 15:04 or 3:04pm
+func FormatTime(date time.Time) string {
+	if Clock12h {
+		return date.Format("3:04pm")
+	}
+	return date.Format("15:04")
+}
+
+// FormatDateTime shows the day and the time of the date
+func FormatDateTime(date time.Time) string {
+	return FormatDate(date) + " " + FormatTime(date)
+}
+
+// FormatShortDateTime shows the day without its year, and the time of the date
+func FormatShortDateTime(date time.Time) string {
+	return FormatShortDate(date) + " " + FormatTime(date)
+}
+
+// dayAndMonth orders the two numbers of a date written without its year, like "12/24" or "24/12".
+// The configured order is used unless only the other one gives a valid date
+func dayAndMonth(first string, second string, separator string) (month string, dayOfMonth string) {
+	month, dayOfMonth = first, second
+	if IsDayFirst() && separator != "-" {
+		month, dayOfMonth = second, first
+	}
+	if m, _ := strconv.Atoi(month); m > 12 {
+		month, dayOfMonth = dayOfMonth, month
+	}
+	return month, dayOfMonth
+}
@@ -31,79 +31,305 @@ package utilities
 
 import (
 	"errors"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
 
-// DateParser : Parses a date given in parameters
-// accepted input :
-// case not sensitive : "yesterday", "today", "tommorow"
-// date in YYYY-MM-DD, YYYY/MM/DD, MM-DD, MM/DD
-func DateParser(dateToParse string) (date time.Time, err error) {
-	date = time.Now()
-	if strings.ToUpper(dateToParse) == "TODAY" {
-		return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local), nil
-	} else if strings.ToUpper(dateToParse) == "YESTERDAY" {
-		date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
-		return date.Add(-24 * time.Hour), nil
-	} else if strings.ToUpper(dateToParse) == "TOMORROW" {
-		date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
-		return date.Add(24 * time.Hour), nil
+// Now is the clock used by the parsers. It can be replaced to parse at a fixed time
+var Now = time.Now
+
+// dateRule is a way of writing a date : a pattern, and how to build the date from what it matched
+type dateRule struct {
+	pattern *regexp.Regexp
+	build   func(now time.Time, matches []string) (time.Time, error)
+}
+
+// weekDayNumbers associates the accepted day names to their weekday
+var weekDayNumbers = map[string]time.Weekday{
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+	"sun": time.Sunday, "sunday": time.Sunday,
+}
+
+const weekDayPattern = `(mon|monday|tue|tues|tuesday|wed|wednesday|thu|thurs|thursday|fri|friday|sat|saturday|sun|sunday)`
+
+// day returns the beginning of the day of the date given in parameters, in its time zone
+func day(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+}
+
+// addUnits adds n days, weeks, months or years to the date
+func addUnits(date time.Time, n int, unit string) time.Time {
+	switch strings.TrimSuffix(unit, "s") {
+	case "d", "day":
+		return date.AddDate(0, 0, n)
+	case "w", "week":
+		return date.AddDate(0, 0, 7*n)
+	case "month":
+		return date.AddDate(0, n, 0)
 	}
-	date, err = time.Parse("2006-01-02", dateToParse)
-	if err == nil {
-		return date, nil
+	return date.AddDate(n, 0, 0)
+}
+
+// numericDate builds a date from the year, month and day matched, in the time zone given in parameters
+func numericDate(year string, month string, dayOfMonth string, location *time.Location) (time.Time, error) {
+	y, _ := strconv.Atoi(year)
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(dayOfMonth)
+	date := time.Date(y, time.Month(m), d, 0, 0, 0, 0, location)
+	if m < 1 || m > 12 || date.Day() != d {
+		return date, errors.New("Wrong date")
 	}
-	// Just the day
-	date, err = time.Parse("02", dateToParse)
-	if err == nil {
+	return date, nil
+}
+
+// dateRules are all the ways of writing a date, tried in order
+var dateRules = []dateRule{
+	{regexp.MustCompile(`^(today|now)$`), func(now time.Time, m []string) (time.Time, error) {
+		return day(now), nil
+	}},
+	{regexp.MustCompile(`^yesterday$`), func(now time.Time, m []string) (time.Time, error) {
+		return day(now).AddDate(0, 0, -1), nil
+	}},
+	{regexp.MustCompile(`^(tomorrow|tommorow)$`), func(now time.Time, m []string) (time.Time, error) {
+		return day(now).AddDate(0, 0, 1), nil
+	}},
+	// "fri" is the next friday (today included), "next fri" the next one after today,
+	// "last fri" the last one before today, "this fri" the one of the current week
+	{regexp.MustCompile(`^(?:(next|last|this) )?` + weekDayPattern + `$`), func(now time.Time, m []string) (time.Time, error) {
+		target := int(weekDayNumbers[m[2]])
+		current := int(now.Weekday())
+		delta := (target - current + 7) % 7
+		switch m[1] {
+		case "next":
+			if delta == 0 {
+				delta = 7
+			}
+		case "last":
+			delta -= 7
+		case "this":
+			delta = (target-int(WeekStart)+7)%7 - (current-int(WeekStart)+7)%7
+		}
+		return day(now).AddDate(0, 0, delta), nil
+	}},
+	// "in 2 days", "in 1 week", "in 3 months", "in 1 year"
+	{regexp.MustCompile(`^in (\d+) (days?|weeks?|months?|years?)$`), func(now time.Time, m []string) (time.Time, error) {
+		n, _ := strconv.Atoi(m[1])
+		return addUnits(day(now), n, m[2]), nil
+	}},
+	// "3 weeks ago"
+	{regexp.MustCompile(`^(\d+) (days?|weeks?|months?|years?) ago$`), func(now time.Time, m []string) (time.Time, error) {
+		n, _ := strconv.Atoi(m[1])
+		return addUnits(day(now), -n, m[2]), nil
+	}},
+	// "+2d", "-1w"
+	{regexp.MustCompile(`^([+-]\d+)(d|w)$`), func(now time.Time, m []string) (time.Time, error) {
+		n, _ := strconv.Atoi(m[1])
+		return addUnits(day(now), n, m[2]), nil
+	}},
+	// ISO week dates : "2026-W42" is the monday of that week, "2026-W42-3" its wednesday
+	{regexp.MustCompile(`^(\d{4})-w(\d{2})(?:-([1-7]))?$`), func(now time.Time, m []string) (time.Time, error) {
+		year, _ := strconv.Atoi(m[1])
+		week, _ := strconv.Atoi(m[2])
+		weekDay := 1
+		if m[3] != "" {
+			weekDay, _ = strconv.Atoi(m[3])
+		}
+		// The 4th of january is always in the first week
+		jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, now.Location())
+		firstMonday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+		date := firstMonday.AddDate(0, 0, 7*(week-1)+weekDay-1)
+		if _, w := date.ISOWeek(); week < 1 || w != week {
+			return date, errors.New("Wrong week")
+		}
 		return date, nil
+	}},
+	{regexp.MustCompile(`^(\d{4})[-/](\d{1,2})[-/](\d{1,2})$`), func(now time.Time, m []string) (time.Time, error) {
+		return numericDate(m[1], m[2], m[3], now.Location())
+	}},
+	// Month and day, or day and month depending on the date format, with the year
+	{regexp.MustCompile(`^(\d{1,2})([-/.])(\d{1,2})[-/.](\d{4})$`), func(now time.Time, m []string) (time.Time, error) {
+		month, dayOfMonth := dayAndMonth(m[1], m[3], m[2])
+		return numericDate(m[4], month, dayOfMonth, now.Location())
+	}},
+	// Month and day, or day and month depending on the date format, of the current year
+	{regexp.MustCompile(`^(\d{1,2})([-/.])(\d{1,2})$`), func(now time.Time, m []string) (time.Time, error) {
+		month, dayOfMonth := dayAndMonth(m[1], m[3], m[2])
+		return numericDate(strconv.Itoa(now.Year()), month, dayOfMonth, now.Location())
+	}},
+	// Just the day of the current month, like "24th" : a bare number is an hour
+	{regexp.MustCompile(`^(\d{1,2})(?:st|nd|rd|th)$`), func(now time.Time, m []string) (time.Time, error) {
+		return numericDate(strconv.Itoa(now.Year()), strconv.Itoa(int(now.Month())), m[1], now.Location())
+	}},
+}
+
+// timeRules are all the ways of writing a time, tried in order. Only the hour, minute and second
+// of the result matter, except for the relative times which are relative to now
+var timeRules = []dateRule{
+	{regexp.MustCompile(`^now$`), func(now time.Time, m []string) (time.Time, error) {
+		return now, nil
+	}},
+	// "15", "15:04", "15:04:05"
+	{regexp.MustCompile(`^([01]?\d|2[0-3])(?::([0-5]\d))?(?::([0-5]\d))?$`), func(now time.Time, m []string) (time.Time, error) {
+		return clockTime(now, m[1], m[2], m[3], ""), nil
+	}},
+	// "3pm", "9:30am", "12am"
+	{regexp.MustCompile(`^(0?[1-9]|1[0-2])(?::([0-5]\d))?(?::([0-5]\d))? ?(am|pm)$`), func(now time.Time, m []string) (time.Time, error) {
+		return clockTime(now, m[1], m[2], m[3], m[4]), nil
+	}},
+	// "+2h", "-15m", "+1h30m"
+	{regexp.MustCompile(`^[+-](\d+(h|m|s))+$`), func(now time.Time, m []string) (time.Time, error) {
+		duration, err := time.ParseDuration(m[0])
+		return now.Add(duration), err
+	}},
+	// "in 2 hours", "in 15 minutes"
+	{regexp.MustCompile(`^in (\d+) (hours?|minutes?|mins?)$`), func(now time.Time, m []string) (time.Time, error) {
+		n, _ := strconv.Atoi(m[1])
+		return now.Add(time.Duration(n) * timeUnit(m[2])), nil
+	}},
+	// "2 hours ago", "15 minutes ago"
+	{regexp.MustCompile(`^(\d+) (hours?|minutes?|mins?) ago$`), func(now time.Time, m []string) (time.Time, error) {
+		n, _ := strconv.Atoi(m[1])
+		return now.Add(-time.Duration(n) * timeUnit(m[2])), nil
+	}},
+}
+
+// clockTime builds the time of the day matched, am/pm being empty for 24 hours times
+func clockTime(now time.Time, hour string, minute string, second string, ampm string) time.Time {
+	h, _ := strconv.Atoi(hour)
+	min, _ := strconv.Atoi(minute)
+	sec, _ := strconv.Atoi(second)
+	if ampm != "" {
+		h = h % 12
+		if ampm == "pm" {
+			h += 12
+		}
 	}
-	date, err = time.Parse("01-02", dateToParse)
-	if err == nil {
-		return time.Date(time.Now().Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local), nil
-	}
-	date, err = time.Parse("2006/01/02", dateToParse)
-	if err == nil {
-		return date, nil
+	return time.Date(now.Year(), now.Month(), now.Day(), h, min, sec, 0, now.Location())
+}
+
+// timeUnit returns the duration of one hour or one minute
+func timeUnit(unit string) time.Duration {
+	if strings.HasPrefix(unit, "h") {
+		return time.Hour
 	}
-	date, err = time.Parse("01/02", dateToParse)
-	if err == nil {
-		return time.Date(time.Now().Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local), nil
+	return time.Minute
+}
+
+// parseWithRules parses the string with the first rule that matches it
+func parseWithRules(str string, rules []dateRule, now time.Time) (time.Time, error) {
+	str = strings.Join(strings.Fields(strings.ToLower(str)), " ")
+	for _, rule := range rules {
+		matches := rule.pattern.FindStringSubmatch(str)
+		if matches != nil {
+			return rule.build(now, matches)
+		}
 	}
-	return time.Now(), errors.New("Wrong formatting")
+	return now, errors.New("Wrong formatting")
+}
+
+// ParseDateAt parses a date as DateParser does, relative to the time given in parameters
+func ParseDateAt(dateToParse string, now time.Time) (time.Time, error) {
+	return parseWithRules(dateToParse, dateRules, now)
+}
+
+// ParseTimeAt parses a time as TimeParser does, relative to the time given in parameters
+func ParseTimeAt(timeStr string, now time.Time) (time.Time, error) {
+	return parseWithRules(timeStr, timeRules, now)
+}
+
+// DateParser : Parses a date given in parameters, at the beginning of the day
+// accepted input, case not sensitive :
+// "yesterday", "today", "tomorrow"
+// day names, like "monday" or "fri" (the next one, today included), "next fri", "last tue", "this wed"
+// relative dates, like "in 2 days", "3 weeks ago", "+2d", "-1w"
+// ISO week dates, like "2026-W42" (the monday of that week) or "2026-W42-3"
+// date in YYYY-MM-DD, YYYY/MM/DD, MM-DD, MM/DD, MM/DD/YYYY, or the day of the month like "24th"
+// (DD/MM and DD/MM/YYYY when the date format is DD/MM, or when the other order is not a valid date)
+// The date is in the time zone given in parameters
+func DateParser(dateToParse string, location *time.Location) (date time.Time, err error) {
+	return ParseDateAt(dateToParse, Now().In(location))
 }
 
 // TimeParser parses a time string
-func TimeParser(timeStr string) (t time.Time, err error) {
-	if strings.ToUpper(timeStr) == "NOW" {
-		return time.Now(), nil
+// accepted input, case not sensitive :
+// "now", "HH", "HH:MM", "HH:MM:SS", 12 hours times like "3pm" or "9:30am"
+// relative times, like "+2h", "-15m", "+1h30m", "in 2 hours", "15 minutes ago"
+// The time is in the time zone given in parameters
+func TimeParser(timeStr string, location *time.Location) (t time.Time, err error) {
+	return ParseTimeAt(timeStr, Now().In(location))
+}
+
+// MergeDateExpressions merges the arguments of a command that together make a date, a time or a period,
+// like "next" "fri", "in" "2" "days" or "last" "month", so that they can be parsed as one argument
+func MergeDateExpressions(command []string) (merged []string) {
+	for i := 0; i < len(command); i++ {
+		found := false
+		for length := 3; length > 1 && !found; length-- {
+			if i+length > len(command) {
+				continue
+			}
+			expression := strings.Join(command[i:i+length], " ")
+			// Only the validity matters, not the time zone
+			_, errDate := ParseDateAt(expression, Now())
+			_, errTime := ParseTimeAt(expression, Now())
+			_, errPeriod := ParsePeriodAt(expression, Now())
+			if errDate == nil || errTime == nil || errPeriod == nil {
+				merged = append(merged, expression)
+				i += length - 1
+				found = true
+			}
+		}
+		if !found {
+			merged = append(merged, command[i])
+		}
 	}
-	// Just the hour
-	t, err = time.Parse("15", timeStr)
-	if err == nil {
-		return t, err
+	return merged
+}
+
+// DateRangeParser parses a range of days given in parameters, as "(date)..(date)" or a single date
+// Returns the first and the last day of the range (both included), in the time zone given in parameters
+func DateRangeParser(rangeToParse string, location *time.Location) (first time.Time, last time.Time, err error) {
+	return parseDateRangeAt(rangeToParse, Now().In(location))
+}
+
+// parseDateRangeAt parses a range of days as DateRangeParser does, relative to the time given in parameters
+func parseDateRangeAt(rangeToParse string, now time.Time) (first time.Time, last time.Time, err error) {
+	bounds := strings.Split(rangeToParse, "..")
+	if len(bounds) > 2 {
+		return first, last, errors.New("Wrong formatting")
 	}
-	// Hour and minute
-	t, err = time.Parse("15:04", timeStr)
-	if err == nil {
-		return t, err
+	first, err = ParseDateAt(bounds[0], now)
+	if err != nil {
+		return first, last, err
+	}
+	last = first
+	if len(bounds) == 2 {
+		last, err = ParseDateAt(bounds[1], now)
+		if err != nil {
+			return first, last, err
+		}
 	}
-	// Hour minute and second
-	t, err = time.Parse("15:04:05", timeStr)
-	if err == nil {
-		return t, err
+	if last.Before(first) {
+		return first, last, errors.New("The range ends before it starts")
 	}
-	return time.Now(), errors.New("Wrong formatting")
+	return first, last, nil
 }
 
 //BuildDateFromDateTime build a date (referenced in parameter with some date string and time string in any format)
-func BuildDateFromDateTime(dateStr string, timeStr string, date *time.Time) (errTime error, errDate error) {
-	*date, errDate = DateParser(dateStr)
+// in the time zone given in parameters
+func BuildDateFromDateTime(dateStr string, timeStr string, location *time.Location, date *time.Time) (errTime error, errDate error) {
+	*date, errDate = DateParser(dateStr, location)
 	if errDate == nil { // Date is correct
-		t, errTime := TimeParser(timeStr)
+		t, errTime := TimeParser(timeStr, location)
 		if errTime == nil { // we have our time
-			*date = time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+			*date = time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, location)
 		}
 	}
 	return errTime, errDate
@@ -111,12 +337,13 @@ func BuildDateFromDateTime(dateStr string, timeStr string, date *time.Time) (err
 }
 
 //BuildDateFromTimeDate build a date (referenced in parameter with some time string and date string in any format)
-func BuildDateFromTimeDate(timeStr string, dateStr string, date *time.Time) (errTime error, errDate error) {
-	*date, errTime = TimeParser(timeStr)
+// in the time zone given in parameters
+func BuildDateFromTimeDate(timeStr string, dateStr string, location *time.Location, date *time.Time) (errTime error, errDate error) {
+	*date, errTime = TimeParser(timeStr, location)
 	if errTime == nil { // we have our time
-		t, errDate := DateParser(dateStr)
+		t, errDate := DateParser(dateStr, location)
 		if errDate == nil { // Date is correct
-			*date = time.Date(t.Year(), t.Month(), t.Day(), date.Hour(), date.Minute(), date.Second(), 0, time.Local)
+			*date = time.Date(t.Year(), t.Month(), t.Day(), date.Hour(), date.Minute(), date.Second(), 0, location)
 		}
 	}
 	return errTime, errDate
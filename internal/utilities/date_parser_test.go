@@ -0,0 +1,291 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package utilities
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// paris is the time zone given to the parsers by the tests, it has daylight saving time
+var paris *time.Location
+
+// setClock pins the clock of the parsers to the time given in parameters, in the Europe/Paris time zone.
+// Returns the function putting back the clock and the settings of the parsers
+func setClock(t *testing.T, year int, month time.Month, dayOfMonth int, hour int, min int) func() {
+	location, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skip("no time zone database : " + err.Error())
+	}
+	paris = location
+	oldNow, oldDateFormat, oldWeekStart := Now, DateFormat, WeekStart
+	now := time.Date(year, month, dayOfMonth, hour, min, 0, 0, paris)
+	Now = func() time.Time { return now }
+	return func() {
+		Now, DateFormat, WeekStart = oldNow, oldDateFormat, oldWeekStart
+	}
+}
+
+// date returns the beginning of the day given in parameters, in the time zone of the tests
+func date(year int, month time.Month, dayOfMonth int) time.Time {
+	return time.Date(year, month, dayOfMonth, 0, 0, 0, 0, paris)
+}
+
+func TestDateParser(t *testing.T) {
+	// A wednesday
+	defer setClock(t, 2026, time.October, 14, 10, 30)()
+
+	tests := []struct {
+		input   string
+		format  string
+		want    time.Time
+		wantErr bool
+	}{
+		{input: "today", want: date(2026, time.October, 14)},
+		{input: "now", want: date(2026, time.October, 14)},
+		{input: " NOW ", want: date(2026, time.October, 14)},
+		{input: "yesterday", want: date(2026, time.October, 13)},
+		{input: "tomorrow", want: date(2026, time.October, 15)},
+		{input: "tommorow", want: date(2026, time.October, 15)},
+		{input: "fri", want: date(2026, time.October, 16)},
+		{input: "wednesday", want: date(2026, time.October, 14)},
+		{input: "next wed", want: date(2026, time.October, 21)},
+		{input: "last wed", want: date(2026, time.October, 7)},
+		{input: "last fri", want: date(2026, time.October, 9)},
+		{input: "this mon", want: date(2026, time.October, 12)},
+		{input: "this sun", want: date(2026, time.October, 18)},
+		{input: "in 2 days", want: date(2026, time.October, 16)},
+		{input: "in 1 week", want: date(2026, time.October, 21)},
+		{input: "in 3 months", want: date(2027, time.January, 14)},
+		{input: "in 1 year", want: date(2027, time.October, 14)},
+		{input: "3 weeks ago", want: date(2026, time.September, 23)},
+		{input: "1 day ago", want: date(2026, time.October, 13)},
+		{input: "+2d", want: date(2026, time.October, 16)},
+		{input: "-1w", want: date(2026, time.October, 7)},
+		{input: "2026-W42", want: date(2026, time.October, 12)},
+		{input: "2026-w42-3", want: date(2026, time.October, 14)},
+		{input: "2026-W01", want: date(2025, time.December, 29)},
+		{input: "2026-10-24", want: date(2026, time.October, 24)},
+		{input: "2026/10/24", want: date(2026, time.October, 24)},
+		{input: "24th", want: date(2026, time.October, 24)},
+		{input: "1st", want: date(2026, time.October, 1)},
+		// A bare number is an hour
+		{input: "24", wantErr: true},
+		{input: "9", wantErr: true},
+		// The month comes first unless the date format is DD/MM
+		{input: "12/10", format: DateFormatISO, want: date(2026, time.December, 10)},
+		{input: "12/10", format: DateFormatMonthDay, want: date(2026, time.December, 10)},
+		{input: "12/10", format: DateFormatDayMonth, want: date(2026, time.October, 12)},
+		{input: "12.10", format: DateFormatDayMonth, want: date(2026, time.October, 12)},
+		{input: "12/10/2027", format: DateFormatMonthDay, want: date(2027, time.December, 10)},
+		{input: "12/10/2027", format: DateFormatDayMonth, want: date(2027, time.October, 12)},
+		// Dashes always put the month first
+		{input: "12-10", format: DateFormatDayMonth, want: date(2026, time.December, 10)},
+		// The other order is used when it is the only valid one
+		{input: "24/12", format: DateFormatMonthDay, want: date(2026, time.December, 24)},
+		{input: "12/24", format: DateFormatDayMonth, want: date(2026, time.December, 24)},
+		{input: "2026-02-30", wantErr: true},
+		{input: "13/13", wantErr: true},
+		{input: "32nd", wantErr: true},
+		{input: "2026-W00", wantErr: true},
+		{input: "2025-W53", wantErr: true},
+		{input: "in two days", wantErr: true},
+		{input: "someday", wantErr: true},
+	}
+	for _, test := range tests {
+		DateFormat = test.format
+		if DateFormat == "" {
+			DateFormat = DateFormatISO
+		}
+		got, err := DateParser(test.input, paris)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("DateParser(%q) with %s = %v, want an error", test.input, DateFormat, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("DateParser(%q) with %s : unexpected error %v", test.input, DateFormat, err)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("DateParser(%q) with %s = %v, want %v", test.input, DateFormat, got, test.want)
+		}
+	}
+}
+
+func TestTimeParser(t *testing.T) {
+	defer setClock(t, 2026, time.October, 14, 10, 30)()
+	now := Now()
+
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{input: "now", want: now},
+		{input: "15", want: time.Date(2026, time.October, 14, 15, 0, 0, 0, paris)},
+		{input: "9:05", want: time.Date(2026, time.October, 14, 9, 5, 0, 0, paris)},
+		{input: "23:59:30", want: time.Date(2026, time.October, 14, 23, 59, 30, 0, paris)},
+		{input: "3pm", want: time.Date(2026, time.October, 14, 15, 0, 0, 0, paris)},
+		{input: "9:30 AM", want: time.Date(2026, time.October, 14, 9, 30, 0, 0, paris)},
+		{input: "12am", want: time.Date(2026, time.October, 14, 0, 0, 0, 0, paris)},
+		{input: "12pm", want: time.Date(2026, time.October, 14, 12, 0, 0, 0, paris)},
+		{input: "+2h", want: now.Add(2 * time.Hour)},
+		{input: "-15m", want: now.Add(-15 * time.Minute)},
+		{input: "+1h30m", want: now.Add(90 * time.Minute)},
+		{input: "in 2 hours", want: now.Add(2 * time.Hour)},
+		{input: "in 1 min", want: now.Add(time.Minute)},
+		{input: "15 minutes ago", want: now.Add(-15 * time.Minute)},
+		{input: "24", wantErr: true},
+		{input: "10:60", wantErr: true},
+		{input: "13pm", wantErr: true},
+		{input: "2h", wantErr: true},
+		{input: "soon", wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := TimeParser(test.input, paris)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("TimeParser(%q) = %v, want an error", test.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("TimeParser(%q) : unexpected error %v", test.input, err)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("TimeParser(%q) = %v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+// Commands like 'plan move ID 9' try the date first, then the time : a bare number has to be an hour
+func TestBareNumbersAreHours(t *testing.T) {
+	defer setClock(t, 2026, time.October, 14, 10, 30)()
+
+	for _, input := range []string{"0", "9", "09", "12", "23"} {
+		if got, err := DateParser(input, paris); err == nil {
+			t.Errorf("DateParser(%q) = %v, want an error", input, got)
+		}
+		hour, _ := strconv.Atoi(input)
+		got, err := TimeParser(input, paris)
+		if err != nil || got.Hour() != hour || got.Minute() != 0 {
+			t.Errorf("TimeParser(%q) = %v, %v, want %d:00", input, got, err, hour)
+		}
+	}
+}
+
+func TestParsersAroundDaylightSavingTime(t *testing.T) {
+	tests := []struct {
+		name  string
+		now   time.Time
+		input string
+		parse func(string, *time.Location) (time.Time, error)
+		want  time.Time
+		// The duration between now and the result, to check that the time really elapsed is kept
+		elapsed time.Duration
+	}{
+		{
+			name:  "tomorrow is the day of the change to winter time",
+			now:   time.Date(2026, time.October, 24, 12, 0, 0, 0, time.UTC),
+			input: "tomorrow", parse: DateParser,
+			want: time.Date(2026, time.October, 25, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "two days later cross the 25 hours day of the change to winter time",
+			now:   time.Date(2026, time.October, 24, 9, 0, 0, 0, time.UTC),
+			input: "in 2 days", parse: DateParser,
+			want:    time.Date(2026, time.October, 26, 0, 0, 0, 0, time.UTC),
+			elapsed: 15*time.Hour + 25*time.Hour,
+		},
+		{
+			name:  "a week before the change to summer time",
+			now:   time.Date(2026, time.April, 1, 8, 0, 0, 0, time.UTC),
+			input: "1 week ago", parse: DateParser,
+			want: time.Date(2026, time.March, 25, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "relative times cross the change to winter time in real time",
+			now:   time.Date(2026, time.October, 25, 1, 30, 0, 0, time.UTC),
+			input: "+2h", parse: TimeParser,
+			want:    time.Date(2026, time.October, 25, 2, 30, 0, 0, time.UTC),
+			elapsed: 2 * time.Hour,
+		},
+		{
+			name:  "relative times cross the change to summer time in real time",
+			now:   time.Date(2026, time.March, 29, 1, 30, 0, 0, time.UTC),
+			input: "in 1 hour", parse: TimeParser,
+			want:    time.Date(2026, time.March, 29, 3, 30, 0, 0, time.UTC),
+			elapsed: time.Hour,
+		},
+		{
+			name:  "clock times are on the summer time of the day",
+			now:   time.Date(2026, time.March, 29, 12, 0, 0, 0, time.UTC),
+			input: "09:00", parse: TimeParser,
+			want:    time.Date(2026, time.March, 29, 9, 0, 0, 0, time.UTC),
+			elapsed: -3 * time.Hour,
+		},
+	}
+	for _, test := range tests {
+		// The dates of the test are wall clock times in Paris, written in UTC to be built before loading it
+		restore := setClock(t, test.now.Year(), test.now.Month(), test.now.Day(), test.now.Hour(), test.now.Minute())
+		want := time.Date(test.want.Year(), test.want.Month(), test.want.Day(), test.want.Hour(), test.want.Minute(), 0, 0, paris)
+		got, err := test.parse(test.input, paris)
+		if err != nil {
+			t.Errorf("%s : unexpected error %v", test.name, err)
+		} else if !got.Equal(want) || got.Format("15:04") != want.Format("15:04") {
+			t.Errorf("%s : parsing %q gave %v, want %v", test.name, test.input, got, want)
+		} else if test.elapsed != 0 && got.Sub(Now()) != test.elapsed {
+			t.Errorf("%s : parsing %q gave %v after now, want %v", test.name, test.input, got.Sub(Now()), test.elapsed)
+		}
+		restore()
+	}
+}
+
+func TestDateRangeParser(t *testing.T) {
+	defer setClock(t, 2026, time.October, 14, 10, 30)()
+
+	first, last, err := DateRangeParser("today..next fri", paris)
+	if err != nil || !first.Equal(date(2026, time.October, 14)) || !last.Equal(date(2026, time.October, 16)) {
+		t.Errorf("DateRangeParser(\"today..next fri\") = %v, %v, %v", first, last, err)
+	}
+	first, last, err = DateRangeParser("2026-12-24", paris)
+	if err != nil || !first.Equal(last) || !first.Equal(date(2026, time.December, 24)) {
+		t.Errorf("DateRangeParser(\"2026-12-24\") = %v, %v, %v", first, last, err)
+	}
+	for _, input := range []string{"tomorrow..yesterday", "today..", "1..2..3"} {
+		if _, _, err := DateRangeParser(input, paris); err == nil {
+			t.Errorf("DateRangeParser(%q) should fail", input)
+		}
+	}
+}
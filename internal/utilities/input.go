@@ -31,8 +31,10 @@ package utilities
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 )
 
 // InputFromUser is a helper function to ask nicely the user of some string to enter and get it
@@ -47,6 +49,26 @@ func InputFromUser(name string) (inputUser string) {
 	return scanner.Text()
 }
 
+// InputSecretFromUser asks the user a secret, like a passphrase, without showing it when the terminal allows it
+func InputSecretFromUser(name string) (string, error) {
+	fmt.Print("Enter " + name + " :")
+	hide := exec.Command("stty", "-echo")
+	hide.Stdin = os.Stdin
+	if hide.Run() == nil {
+		defer func() {
+			show := exec.Command("stty", "echo")
+			show.Stdin = os.Stdin
+			show.Run()
+			fmt.Println()
+		}()
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	if !scanner.Scan() {
+		return "", errors.New("Could not read the " + name)
+	}
+	return scanner.Text(), nil
+}
+
 // AskOkFromUser is a helper function to ask nicely the user if he/she's okay to perform some action
 func AskOkFromUser(str string) bool {
 
@@ -0,0 +1,196 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package utilities
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WeekStart is the first day of the weeks, for "this week" or "last week" periods
+var WeekStart = time.Monday
+
+// Period is a range of days, from the beginning of Begin to End (excluded)
+type Period struct {
+	Begin time.Time
+	End   time.Time
+}
+
+// Days returns the number of days in the period
+func (period Period) Days() int {
+	days := 0
+	for day := period.Begin; day.Before(period.End); day = day.AddDate(0, 0, 1) {
+		days++
+	}
+	return days
+}
+
+// String shows the period as its first and last days
+func (period Period) String() string {
+	last := period.End.AddDate(0, 0, -1)
+	if !last.After(period.Begin) {
+		return FormatDate(period.Begin)
+	}
+	return FormatDate(period.Begin) + ".." + FormatDate(last)
+}
+
+// weekBeginning returns the first day of the week of the date given in parameters
+func weekBeginning(date time.Time) time.Time {
+	return day(date).AddDate(0, 0, -((int(date.Weekday()) - int(WeekStart) + 7) % 7))
+}
+
+// unitPeriod returns the day, week, month, quarter or year containing the date given in parameters
+func unitPeriod(date time.Time, unit string) Period {
+	var begin time.Time
+	switch unit {
+	case "day":
+		begin = day(date)
+		return Period{begin, begin.AddDate(0, 0, 1)}
+	case "week":
+		begin = weekBeginning(date)
+		return Period{begin, begin.AddDate(0, 0, 7)}
+	case "month":
+		begin = time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+		return Period{begin, begin.AddDate(0, 1, 0)}
+	case "quarter":
+		begin = time.Date(date.Year(), date.Month()-(date.Month()-1)%3, 1, 0, 0, 0, 0, date.Location())
+		return Period{begin, begin.AddDate(0, 3, 0)}
+	}
+	begin = time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, date.Location())
+	return Period{begin, begin.AddDate(1, 0, 0)}
+}
+
+// periodRule is a way of writing a period : a pattern, and how to build the period from what it matched
+type periodRule struct {
+	pattern *regexp.Regexp
+	build   func(now time.Time, matches []string) (Period, error)
+}
+
+// periodRules are all the ways of writing a period, tried in order
+var periodRules = []periodRule{
+	// "this week", "last month", "next quarter", "last year"
+	{regexp.MustCompile(`^(this|last|next) (day|week|month|quarter|year)$`), func(now time.Time, m []string) (Period, error) {
+		offset := map[string]int{"this": 0, "last": -1, "next": 1}[m[1]]
+		switch m[2] {
+		case "day":
+			now = now.AddDate(0, 0, offset)
+		case "week":
+			now = now.AddDate(0, 0, 7*offset)
+		case "month":
+			now = time.Date(now.Year(), now.Month()+time.Month(offset), 1, 0, 0, 0, 0, now.Location())
+		case "quarter":
+			now = time.Date(now.Year(), now.Month()+time.Month(3*offset), 1, 0, 0, 0, 0, now.Location())
+		case "year":
+			now = time.Date(now.Year()+offset, time.January, 1, 0, 0, 0, 0, now.Location())
+		}
+		return unitPeriod(now, m[2]), nil
+	}},
+	// From the beginning of the week, month or year to today included
+	{regexp.MustCompile(`^(wtd|mtd|ytd)$`), func(now time.Time, m []string) (Period, error) {
+		unit := map[string]string{"wtd": "week", "mtd": "month", "ytd": "year"}[m[1]]
+		return Period{unitPeriod(now, unit).Begin, day(now).AddDate(0, 0, 1)}, nil
+	}},
+	// "last 30d", "last 2 weeks" : the last days, today included
+	{regexp.MustCompile(`^last (\d+) ?(d|w|days?|weeks?|months?)$`), func(now time.Time, m []string) (Period, error) {
+		n, _ := strconv.Atoi(m[1])
+		if n < 1 {
+			return Period{}, errors.New("Wrong period")
+		}
+		end := day(now).AddDate(0, 0, 1)
+		return Period{addUnits(end, -n, m[2]), end}, nil
+	}},
+	// "2026-Q3"
+	{regexp.MustCompile(`^(\d{4})-q([1-4])$`), func(now time.Time, m []string) (Period, error) {
+		year, _ := strconv.Atoi(m[1])
+		quarter, _ := strconv.Atoi(m[2])
+		return unitPeriod(time.Date(year, time.Month(3*quarter-2), 1, 0, 0, 0, 0, now.Location()), "quarter"), nil
+	}},
+	// "2026-09"
+	{regexp.MustCompile(`^(\d{4})-(\d{2})$`), func(now time.Time, m []string) (Period, error) {
+		begin, err := numericDate(m[1], m[2], "1", now.Location())
+		return Period{begin, begin.AddDate(0, 1, 0)}, err
+	}},
+	// "2026-W42", a week is always from monday to sunday in that format
+	{regexp.MustCompile(`^\d{4}-w\d{2}$`), func(now time.Time, m []string) (Period, error) {
+		begin, err := ParseDateAt(m[0], now)
+		return Period{begin, begin.AddDate(0, 0, 7)}, err
+	}},
+	// "2026"
+	{regexp.MustCompile(`^(\d{4})$`), func(now time.Time, m []string) (Period, error) {
+		year, _ := strconv.Atoi(m[1])
+		return unitPeriod(time.Date(year, time.January, 1, 0, 0, 0, 0, now.Location()), "year"), nil
+	}},
+	// "2026-10-01..2026-10-15", or a single day
+	{regexp.MustCompile(`^.+$`), func(now time.Time, m []string) (Period, error) {
+		first, last, err := parseDateRangeAt(m[0], now)
+		return Period{first, last.AddDate(0, 0, 1)}, err
+	}},
+}
+
+// ParsePeriodAt parses a period as PeriodParser does for one argument, relative to the time given in parameters
+func ParsePeriodAt(periodToParse string, now time.Time) (Period, error) {
+	str := strings.Join(strings.Fields(strings.ToLower(periodToParse)), " ")
+	for _, rule := range periodRules {
+		matches := rule.pattern.FindStringSubmatch(str)
+		if matches != nil {
+			return rule.build(now, matches)
+		}
+	}
+	return Period{}, errors.New("Wrong formatting")
+}
+
+// PeriodParser parses the arguments of a command that give a period, today if there are none
+// accepted input, case not sensitive :
+// "this week", "last month", "next quarter", "last year", "wtd", "mtd", "ytd"
+// "last 30d", "last 2 weeks", "2026-Q3", "2026-09", "2026-W42", "2026"
+// "(date)..(date)", or "(date) (nbDays)"
+// The days of the period are the ones of the time zone given in parameters
+func PeriodParser(args []string, location *time.Location) (period Period, err error) {
+	now := Now().In(location)
+	if len(args) == 0 {
+		return unitPeriod(now, "day"), nil
+	}
+	period, err = ParsePeriodAt(args[0], now)
+	if err != nil {
+		return period, err
+	}
+	if len(args) > 1 {
+		// A number of days from the first day of the period
+		nbDays, err := strconv.Atoi(args[1])
+		if err != nil || nbDays < 1 {
+			return period, errors.New("Wrong argument '" + args[1] + "', should be a positive number")
+		}
+		period.End = period.Begin.AddDate(0, 0, nbDays)
+	}
+	return period, nil
+}
@@ -0,0 +1,187 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package utilities
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePeriodAt(t *testing.T) {
+	// A wednesday, the week before the change to winter time
+	defer setClock(t, 2026, time.October, 14, 10, 30)()
+
+	tests := []struct {
+		input       string
+		sundayFirst bool
+		begin       time.Time
+		end         time.Time
+		wantErr     bool
+	}{
+		{input: "this day", begin: date(2026, time.October, 14), end: date(2026, time.October, 15)},
+		{input: "last day", begin: date(2026, time.October, 13), end: date(2026, time.October, 14)},
+		{input: "this week", begin: date(2026, time.October, 12), end: date(2026, time.October, 19)},
+		{input: "This  Week", begin: date(2026, time.October, 12), end: date(2026, time.October, 19)},
+		{input: "this week", sundayFirst: true, begin: date(2026, time.October, 11), end: date(2026, time.October, 18)},
+		{input: "last week", begin: date(2026, time.October, 5), end: date(2026, time.October, 12)},
+		{input: "next week", begin: date(2026, time.October, 19), end: date(2026, time.October, 26)},
+		{input: "this month", begin: date(2026, time.October, 1), end: date(2026, time.November, 1)},
+		{input: "last month", begin: date(2026, time.September, 1), end: date(2026, time.October, 1)},
+		{input: "next month", begin: date(2026, time.November, 1), end: date(2026, time.December, 1)},
+		{input: "this quarter", begin: date(2026, time.October, 1), end: date(2027, time.January, 1)},
+		{input: "last quarter", begin: date(2026, time.July, 1), end: date(2026, time.October, 1)},
+		{input: "next quarter", begin: date(2027, time.January, 1), end: date(2027, time.April, 1)},
+		{input: "last year", begin: date(2025, time.January, 1), end: date(2026, time.January, 1)},
+		{input: "wtd", begin: date(2026, time.October, 12), end: date(2026, time.October, 15)},
+		{input: "mtd", begin: date(2026, time.October, 1), end: date(2026, time.October, 15)},
+		{input: "ytd", begin: date(2026, time.January, 1), end: date(2026, time.October, 15)},
+		{input: "last 30d", begin: date(2026, time.September, 15), end: date(2026, time.October, 15)},
+		{input: "last 2 weeks", begin: date(2026, time.October, 1), end: date(2026, time.October, 15)},
+		{input: "last 1 month", begin: date(2026, time.September, 15), end: date(2026, time.October, 15)},
+		{input: "2026-Q3", begin: date(2026, time.July, 1), end: date(2026, time.October, 1)},
+		{input: "2026-09", begin: date(2026, time.September, 1), end: date(2026, time.October, 1)},
+		{input: "2026-W42", begin: date(2026, time.October, 12), end: date(2026, time.October, 19)},
+		// ISO weeks start on monday whatever the week start
+		{input: "2026-W42", sundayFirst: true, begin: date(2026, time.October, 12), end: date(2026, time.October, 19)},
+		{input: "2026", begin: date(2026, time.January, 1), end: date(2027, time.January, 1)},
+		{input: "2026-10-01..2026-10-15", begin: date(2026, time.October, 1), end: date(2026, time.October, 16)},
+		{input: "yesterday..tomorrow", begin: date(2026, time.October, 13), end: date(2026, time.October, 16)},
+		{input: "today", begin: date(2026, time.October, 14), end: date(2026, time.October, 15)},
+		{input: "2026-13", wantErr: true},
+		{input: "last 0d", wantErr: true},
+		{input: "2026-10-15..2026-10-01", wantErr: true},
+		{input: "whenever", wantErr: true},
+	}
+	for _, test := range tests {
+		WeekStart = time.Monday
+		if test.sundayFirst {
+			WeekStart = time.Sunday
+		}
+		got, err := ParsePeriodAt(test.input, Now())
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParsePeriodAt(%q) = %v, want an error", test.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePeriodAt(%q) : unexpected error %v", test.input, err)
+			continue
+		}
+		if !got.Begin.Equal(test.begin) || !got.End.Equal(test.end) {
+			t.Errorf("ParsePeriodAt(%q) = %v -> %v, want %v -> %v", test.input, got.Begin, got.End, test.begin, test.end)
+		}
+	}
+}
+
+func TestPeriodParser(t *testing.T) {
+	defer setClock(t, 2026, time.October, 14, 10, 30)()
+
+	tests := []struct {
+		args    []string
+		begin   time.Time
+		end     time.Time
+		wantErr bool
+	}{
+		{args: nil, begin: date(2026, time.October, 14), end: date(2026, time.October, 15)},
+		{args: []string{"2026-10-01", "3"}, begin: date(2026, time.October, 1), end: date(2026, time.October, 4)},
+		{args: []string{"this week", "2"}, begin: date(2026, time.October, 12), end: date(2026, time.October, 14)},
+		{args: []string{"2026-10-01", "0"}, wantErr: true},
+		{args: []string{"2026-10-01", "three"}, wantErr: true},
+		{args: []string{"someday"}, wantErr: true},
+	}
+	for _, test := range tests {
+		got, err := PeriodParser(test.args, paris)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("PeriodParser(%q) = %v, want an error", test.args, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("PeriodParser(%q) : unexpected error %v", test.args, err)
+			continue
+		}
+		if !got.Begin.Equal(test.begin) || !got.End.Equal(test.end) {
+			t.Errorf("PeriodParser(%q) = %v -> %v, want %v -> %v", test.args, got.Begin, got.End, test.begin, test.end)
+		}
+	}
+}
+
+func TestPeriodAroundDaylightSavingTime(t *testing.T) {
+	// The days of the test are written in UTC to be built before loading the time zone
+	day := func(year int, month time.Month, dayOfMonth int) time.Time {
+		return time.Date(year, month, dayOfMonth, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name   string
+		now    time.Time
+		input  string
+		begin  time.Time
+		end    time.Time
+		nbDays int
+	}{
+		{
+			name: "the week of the change to winter time",
+			now:  day(2026, time.October, 21), input: "this week",
+			begin: day(2026, time.October, 19), end: day(2026, time.October, 26), nbDays: 7,
+		},
+		{
+			name: "the month of the change to summer time",
+			now:  day(2026, time.March, 10), input: "this month",
+			begin: day(2026, time.March, 1), end: day(2026, time.April, 1), nbDays: 31,
+		},
+		{
+			name: "the last days, the change to summer time included",
+			now:  day(2026, time.April, 2), input: "last 7d",
+			begin: day(2026, time.March, 27), end: day(2026, time.April, 3), nbDays: 7,
+		},
+		{
+			name: "a range of days over the change to winter time",
+			now:  day(2026, time.October, 21), input: "2026-10-24..2026-10-26",
+			begin: day(2026, time.October, 24), end: day(2026, time.October, 27), nbDays: 3,
+		},
+	}
+	for _, test := range tests {
+		// The dates of the test are loaded again in the time zone with daylight saving time
+		restore := setClock(t, test.now.Year(), test.now.Month(), test.now.Day(), 12, 0)
+		begin := date(test.begin.Year(), test.begin.Month(), test.begin.Day())
+		end := date(test.end.Year(), test.end.Month(), test.end.Day())
+		got, err := ParsePeriodAt(test.input, Now())
+		if err != nil {
+			t.Errorf("%s : unexpected error %v", test.name, err)
+		} else if !got.Begin.Equal(begin) || !got.End.Equal(end) {
+			t.Errorf("%s : %v -> %v, want %v -> %v", test.name, got.Begin, got.End, begin, end)
+		} else if got.Days() != test.nbDays {
+			t.Errorf("%s : %d days, want %d", test.name, got.Days(), test.nbDays)
+		}
+		restore()
+	}
+}
@@ -41,6 +41,8 @@ type EventStored struct {
 	Name string `json:"name"`
 	// CalendarID
 	CalendarID string `json:"CalendarID"`
+	// RecurringEventID is the id of the series, if the event is an occurrence of a recurring event
+	RecurringEventID string `json:"RecurringEventID,omitempty"`
 }
 
 // Plan is the type of the stored plan with ID of events to be modified
@@ -49,9 +51,17 @@ type Plan struct {
 	Events []EventStored `json:"events"`
 }
 
+// Dir is the folder where the plan is stored, the one of the profile, so that each profile has its own
+var Dir string
+
+// planPath returns the path of the file holding the stored plan
+func planPath() string {
+	return Dir + "/plan.json"
+}
+
 // LoadPlan loads the stored plan
 func LoadPlan() (plan Plan, err error) {
-	f, err := os.Open("/tmp/plan")
+	f, err := os.Open(planPath())
 	if err != nil {
 		return plan, err
 	}
@@ -63,8 +73,12 @@ func LoadPlan() (plan Plan, err error) {
 
 // StorePlan saves the stored plan
 func StorePlan(plan *Plan) (err error) {
-	os.Remove("/tmp/plan")
-	f, err := os.Create("/tmp/plan")
+	err = os.MkdirAll(Dir, 0700)
+	if err != nil {
+		return err
+	}
+	os.Remove(planPath())
+	f, err := os.Create(planPath())
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,171 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+/*
+ ============= GOGENDA SOURCE CODE ===========
+ @Description : GoGenda is a CLI for google agenda, to focus on one task at a time and logs your activity
+ @Author : Julien LE THENO
+ =============================================
+*/
+package utilities
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// weekDays associates the accepted day names to their iCalendar code
+var weekDays = map[string]string{
+	"MON": "MO", "MONDAY": "MO",
+	"TUE": "TU", "TUES": "TU", "TUESDAY": "TU",
+	"WED": "WE", "WEDNESDAY": "WE",
+	"THU": "TH", "THURS": "TH", "THURSDAY": "TH",
+	"FRI": "FR", "FRIDAY": "FR",
+	"SAT": "SA", "SATURDAY": "SA",
+	"SUN": "SU", "SUNDAY": "SU",
+}
+
+// frequencies associates the accepted frequency words to their iCalendar frequency
+var frequencies = map[string]string{
+	"DAY": "DAILY", "DAYS": "DAILY", "DAILY": "DAILY",
+	"WEEK": "WEEKLY", "WEEKS": "WEEKLY", "WEEKLY": "WEEKLY",
+	"MONTH": "MONTHLY", "MONTHS": "MONTHLY", "MONTHLY": "MONTHLY",
+	"YEAR": "YEARLY", "YEARS": "YEARLY", "YEARLY": "YEARLY",
+}
+
+// parseDayList parses a list of days like "mon,thu" into "MO,TH"
+func parseDayList(list string) (string, error) {
+	var days []string
+	for _, day := range strings.Split(list, ",") {
+		code, ok := weekDays[strings.ToUpper(day)]
+		if !ok {
+			return "", errors.New("Unknown day '" + day + "'")
+		}
+		days = append(days, code)
+	}
+	return strings.Join(days, ","), nil
+}
+
+// IsRecurrenceStart checks if the word can be the first word of a recurrence
+func IsRecurrenceStart(word string) bool {
+	word = strings.ToUpper(word)
+	return word == "EVERY" || word == "DAILY" || word == "WEEKLY" || word == "MONTHLY" || word == "YEARLY"
+}
+
+// RecurrenceParser parses a recurrence given as words into an iCalendar RRULE
+// accepted input, case not sensitive :
+// (every) weekday | day | week | month | year | daily | weekly | monthly | yearly | (n) days|weeks|months|years | mon,thu..
+// followed by any of : on mon,thu..  -  until (date)  -  for (n) times
+// For example "every weekday", "weekly on mon,thu until 2026-12-31", "every 2 weeks for 10 times"
+func RecurrenceParser(words []string, location *time.Location) (rule string, err error) {
+	if len(words) > 0 && strings.ToUpper(words[0]) == "EVERY" {
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return "", errors.New("Empty recurrence")
+	}
+	var frequency, interval, byDay, until, count string
+
+	// Frequency
+	word := strings.ToUpper(words[0])
+	words = words[1:]
+	if word == "WEEKDAY" || word == "WEEKDAYS" {
+		frequency = "WEEKLY"
+		byDay = "MO,TU,WE,TH,FR"
+	} else if f, ok := frequencies[word]; ok {
+		frequency = f
+	} else if n, errAtoi := strconv.Atoi(word); errAtoi == nil && n > 0 && len(words) > 0 {
+		f, ok := frequencies[strings.ToUpper(words[0])]
+		if !ok {
+			return "", errors.New("Unknown frequency '" + words[0] + "'")
+		}
+		frequency = f
+		interval = word
+		words = words[1:]
+	} else if days, errDays := parseDayList(word); errDays == nil {
+		frequency = "WEEKLY"
+		byDay = days
+	} else {
+		return "", errors.New("Unknown frequency '" + word + "'")
+	}
+
+	// Options
+	for len(words) > 0 {
+		option := strings.ToUpper(words[0])
+		if len(words) < 2 {
+			return "", errors.New("Missing value after '" + words[0] + "'")
+		}
+		switch option {
+		case "ON":
+			if frequency != "WEEKLY" {
+				return "", errors.New("Days can only be given for weekly recurrences")
+			}
+			byDay, err = parseDayList(words[1])
+			if err != nil {
+				return "", err
+			}
+			words = words[2:]
+		case "UNTIL":
+			date, err := DateParser(words[1], location)
+			if err != nil {
+				return "", err
+			}
+			// The whole last day is included
+			lastDay := time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 0, location)
+			until = lastDay.UTC().Format("20060102T150405Z")
+			words = words[2:]
+		case "FOR":
+			n, errAtoi := strconv.Atoi(words[1])
+			if errAtoi != nil || n < 1 {
+				return "", errors.New("Wrong number of occurrences '" + words[1] + "'")
+			}
+			count = words[1]
+			words = words[2:]
+			if len(words) > 0 && (strings.ToUpper(words[0]) == "TIMES" || strings.ToUpper(words[0]) == "OCCURRENCES") {
+				words = words[1:]
+			}
+		default:
+			return "", errors.New("Unknown recurrence option '" + words[0] + "'")
+		}
+	}
+	if until != "" && count != "" {
+		return "", errors.New("A recurrence cannot have both 'until' and 'for'")
+	}
+
+	rule = "RRULE:FREQ=" + frequency
+	if interval != "" {
+		rule += ";INTERVAL=" + interval
+	}
+	if byDay != "" {
+		rule += ";BYDAY=" + byDay
+	}
+	if until != "" {
+		rule += ";UNTIL=" + until
+	}
+	if count != "" {
+		rule += ";COUNT=" + count
+	}
+	return rule, nil
+}
@@ -24,25 +24,122 @@ SOFTWARE.
 package google_agenda_api
 
 import (
+	"context"
 	"errors"
 	"time"
 
 	"google.golang.org/api/calendar/v3"
 )
 
-// InsertActivity : Inserts an activity in the agenda
+// Kinds of activities : planned ahead, or logged while (or after) doing them
+const (
+	KindPlanned = "planned"
+	KindLogged  = "logged"
+)
+
+// kindProperty is the private extended property of the events that holds their kind
+const kindProperty = "gogendaKind"
+
+// estimateProperty is the private extended property of the events that holds the time estimated to do them
+const estimateProperty = "gogendaEstimate"
+
+// InsertActivity : Inserts a logged activity in the agenda
 // with the name of the event and the color of the event you want, the start and end time
 // colors can be : "red", "yellow", "purple", "orange", "blue"
 // Also give a pointer the the calendar service in order to send the api.
 // It will return, if it succeeds, the event created, and an error code in case it fails.
-func InsertActivity(name string, color string, beginTime time.Time, endTime time.Time, srv *calendar.Service) (activity calendar.Event, err error) {
-	var newEvent calendar.Event
-	var edtStart calendar.EventDateTime
-	var edtEnd calendar.EventDateTime
-	edtStart.DateTime = beginTime.Format(time.RFC3339)
-	edtEnd.DateTime = endTime.Format(time.RFC3339)
-	newEvent.Start = &edtStart
-	newEvent.End = &edtEnd
+func InsertActivity(ctx context.Context, name string, color string, beginTime time.Time, endTime time.Time, srv *calendar.Service) (activity calendar.Event, err error) {
+	return insertActivity(ctx, name, color, timedDate(beginTime), timedDate(endTime), map[string]string{kindProperty: KindLogged}, nil, srv)
+}
+
+// InsertEstimatedActivity : Inserts a logged activity in the agenda, with the time estimated to do it
+// Also give a pointer the the calendar service in order to send the api.
+// It will return, if it succeeds, the event created, and an error code in case it fails.
+func InsertEstimatedActivity(ctx context.Context, name string, color string, beginTime time.Time, endTime time.Time, estimate time.Duration, srv *calendar.Service) (activity calendar.Event, err error) {
+	properties := map[string]string{kindProperty: KindLogged, estimateProperty: estimate.String()}
+	return insertActivity(ctx, name, color, timedDate(beginTime), timedDate(endTime), properties, nil, srv)
+}
+
+// InsertPlannedActivity : Inserts a planned activity in the agenda, repeated with the recurrence rules
+// given in parameters (like "RRULE:FREQ=WEEKLY;BYDAY=MO,TH"). No recurrence means a one-off activity.
+// Also give a pointer the the calendar service in order to send the api.
+// It will return, if it succeeds, the event created, and an error code in case it fails.
+func InsertPlannedActivity(ctx context.Context, name string, color string, beginTime time.Time, endTime time.Time, recurrence []string, srv *calendar.Service) (activity calendar.Event, err error) {
+	return insertActivity(ctx, name, color, timedDate(beginTime), timedDate(endTime), map[string]string{kindProperty: KindPlanned}, recurrence, srv)
+}
+
+// InsertAllDayActivity : Inserts a planned activity in the agenda that takes whole days, like holidays,
+// from the first day to the last day given in parameters (both included)
+// Also give a pointer the the calendar service in order to send the api.
+// It will return, if it succeeds, the event created, and an error code in case it fails.
+func InsertAllDayActivity(ctx context.Context, name string, color string, firstDay time.Time, lastDay time.Time, srv *calendar.Service) (activity calendar.Event, err error) {
+	start := &calendar.EventDateTime{Date: firstDay.Format("2006-01-02")}
+	// The end of all day events is the day after the last one
+	end := &calendar.EventDateTime{Date: lastDay.AddDate(0, 0, 1).Format("2006-01-02")}
+	return insertActivity(ctx, name, color, start, end, map[string]string{kindProperty: KindPlanned}, nil, srv)
+}
+
+// IsAllDay returns true for the events that take whole days instead of a time slot
+func IsAllDay(activity *calendar.Event) bool {
+	return activity.Start != nil && activity.Start.DateTime == "" && activity.Start.Date != ""
+}
+
+// movedAllDayDates returns the dates of an all day event moved to the day given in parameters,
+// keeping its number of days
+func movedAllDayDates(oldStart time.Time, oldEnd time.Time, day time.Time) (start *calendar.EventDateTime, end *calendar.EventDateTime) {
+	nbDays := int(oldEnd.Sub(oldStart).Hours()/24 + 0.5)
+	start = &calendar.EventDateTime{Date: day.Format("2006-01-02")}
+	end = &calendar.EventDateTime{Date: day.AddDate(0, 0, nbDays).Format("2006-01-02")}
+	return start, end
+}
+
+// timedDate returns the date of an event that starts or ends at a given time
+func timedDate(date time.Time) *calendar.EventDateTime {
+	return &calendar.EventDateTime{DateTime: date.Format(time.RFC3339)}
+}
+
+// GetActivityKind returns whether the activity was planned or logged.
+// Activities that gogenda did not mark are considered logged
+func GetActivityKind(activity *calendar.Event) string {
+	if activity.ExtendedProperties != nil && activity.ExtendedProperties.Private[kindProperty] == KindPlanned {
+		return KindPlanned
+	}
+	return KindLogged
+}
+
+// GetActivityEstimate returns the time estimated to do the activity, false if there is no estimate
+func GetActivityEstimate(activity *calendar.Event) (time.Duration, bool) {
+	if activity.ExtendedProperties == nil || activity.ExtendedProperties.Private[estimateProperty] == "" {
+		return 0, false
+	}
+	estimate, err := time.ParseDuration(activity.ExtendedProperties.Private[estimateProperty])
+	return estimate, err == nil
+}
+
+// insertActivity : Inserts an activity with the private extended properties given in parameters in the agenda
+func insertActivity(ctx context.Context, name string, color string, start *calendar.EventDateTime, end *calendar.EventDateTime, properties map[string]string, recurrence []string, srv *calendar.Service) (activity calendar.Event, err error) {
+	newEvent := newActivity(name, color, start, end, properties)
+	if len(recurrence) > 0 {
+		// Google needs to know in which time zone the occurrences are expanded
+		var cal *calendar.Calendar
+		err := retry(ctx, "get calendar", true, func(ctx context.Context) (err error) {
+			cal, err = srv.Calendars.Get("primary").Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			return newEvent, err
+		}
+		start.TimeZone = cal.TimeZone
+		end.TimeZone = cal.TimeZone
+		newEvent.Recurrence = recurrence
+	}
+	return sendNewActivity(ctx, newEvent, srv)
+}
+
+// newActivity returns the event of an activity, not sent yet
+func newActivity(name string, color string, start *calendar.EventDateTime, end *calendar.EventDateTime, properties map[string]string) (newEvent calendar.Event) {
+	newEvent.Start = start
+	newEvent.End = end
 	// 1 is lavender
 	// 2 is green (sauge)
 	// 3 is purple
@@ -69,59 +166,94 @@ func InsertActivity(name string, color string, beginTime time.Time, endTime time
 	}
 	// No necessary default case as ColorId doesnt have to be set
 	newEvent.Summary = name
-	call := srv.Events.Insert("primary", &newEvent)
-	actualEvent, err := call.Do()
+	newEvent.ExtendedProperties = &calendar.EventExtendedProperties{Private: properties}
+	return newEvent
+}
+
+// sendNewActivity inserts the event of an activity, and records it in the journal.
+// Offline, it is kept in the outbox and returned with a provisional id
+func sendNewActivity(ctx context.Context, newEvent calendar.Event, srv *calendar.Service) (activity calendar.Event, err error) {
+	actualEvent, err := insertEvent(ctx, &newEvent, srv)
+	if isOffline(err) {
+		// Keep it to send it later, with a provisional id
+		queued, err := queueInsert(&newEvent)
+		if err != nil {
+			return newEvent, err
+		}
+		return *queued, nil
+	}
+	if err != nil {
+		return newEvent, err
+	}
 	newEvent.Id = actualEvent.Id
+	newEvent.Etag = actualEvent.Etag
+	recordMutation(OperationInsert, nil, actualEvent)
 	return newEvent, err
 }
 
 // StopActivity : Stops the current activity : actually update the end time of the activity in parameters
 // to be current time.
+// Only the end time is sent, and only if the event didn't change meanwhile : returns a *ConflictError otherwise.
 // Also give a pointer the the calendar service in order to send the api.
-func StopActivity(activity *calendar.Event, srv *calendar.Service) (err error) {
+func StopActivity(ctx context.Context, activity *calendar.Event, srv *calendar.Service) (err error) {
 	var edtEnd calendar.EventDateTime
 	edtEnd.DateTime = time.Now().Format(time.RFC3339)
+	_, err = patchActivity(ctx, activity, &calendar.Event{End: &edtEnd}, srv)
+	if err != nil {
+		return err
+	}
 	activity.End = &edtEnd
-	call := srv.Events.Update("primary", activity.Id, activity)
-	_, err = call.Do()
 	activity.Id = ""
-	return err
+	return nil
 }
 
 // DeleteActivity : Deletes the activity given in parameters
 // Also give a pointer the the calendar service in order to send the api.
-func DeleteActivity(activity *calendar.Event, srv *calendar.Service) (err error) {
-	call := srv.Events.Delete("primary", activity.Id)
-	err = call.Do()
+func DeleteActivity(ctx context.Context, activity *calendar.Event, srv *calendar.Service) (err error) {
+	if IsProvisionalID(activity.Id) {
+		// Never sent, it only has to be forgotten
+		err = dropQueuedEvent(activity.Id)
+		activity.Id = ""
+		return err
+	}
+	err = deleteEvent(ctx, activity.Id, srv)
+	if err == nil {
+		recordMutation(OperationDelete, activity, nil)
+	}
 	activity.Id = ""
 	return err
 }
 
 // DeleteActivityFromID : Deletes the activity related to the idgiven in parameters
 // Also give a pointer the the calendar service in order to send the api.
-func DeleteActivityFromID(EventID string, srv *calendar.Service) (err error) {
-	call := srv.Events.Delete("primary", EventID)
-	err = call.Do()
-	return err
+func DeleteActivityFromID(ctx context.Context, EventID string, srv *calendar.Service) (err error) {
+	// Keep the event for the journal
+	before, err := getEvent(ctx, EventID, srv)
+	if err != nil {
+		return err
+	}
+	return DeleteActivity(ctx, before, srv)
 }
 
 // MoveActivityFromID : Moves the activity with the datetime given in parareters
 // Set the start time to the one in param, and stop time will be changed accordingly
 // to keep the same duration
-func MoveActivityFromID(EventID string, startTime time.Time, srv *calendar.Service) (err error) {
-	request := srv.Events.Get("primary", EventID)
-	event, err := request.Do()
+func MoveActivityFromID(ctx context.Context, EventID string, startTime time.Time, srv *calendar.Service) (err error) {
+	event, err := getEvent(ctx, EventID, srv)
+	if err != nil {
+		return err
+	}
 
 	// Getting the duration of the activity
-	oldEndTime, _ := time.Parse(time.RFC3339, event.End.DateTime)
-	oldStartTime, _ := time.Parse(time.RFC3339, event.Start.DateTime)
+	oldEndTime, _ := GetEventEnd(event, startTime.Location())
+	oldStartTime, _ := GetEventStart(event, startTime.Location())
 	duration := oldEndTime.Sub(oldStartTime)
 
-	event.Start.DateTime = startTime.Format(time.RFC3339)
-	event.End.DateTime = startTime.Add(duration).Format(time.RFC3339)
-
-	call := srv.Events.Update("primary", EventID, event)
-	event, err = call.Do()
+	patch := &calendar.Event{Start: timedDate(startTime), End: timedDate(startTime.Add(duration))}
+	if IsAllDay(event) {
+		patch.Start, patch.End = movedAllDayDates(oldStartTime, oldEndTime, startTime)
+	}
+	_, err = patchActivity(ctx, event, patch, srv)
 	// Todo check if it becomes the current event or not ?
 	return err
 }
@@ -129,58 +261,182 @@ func MoveActivityFromID(EventID string, startTime time.Time, srv *calendar.Servi
 // CopyActivityFromID : Copy the activity with the datetime given in parareters
 // Set the start time to the one in param, and stop time will be changed accordingly
 // to keep the same duration
-func CopyActivityFromID(EventID string, startTime time.Time, srv *calendar.Service) (err error) {
-	request := srv.Events.Get("primary", EventID)
-	event, err := request.Do()
+func CopyActivityFromID(ctx context.Context, EventID string, startTime time.Time, srv *calendar.Service) (err error) {
+	event, err := getEvent(ctx, EventID, srv)
+	if err != nil {
+		return err
+	}
 
 	// Getting the duration of the activity
-	oldEndTime, _ := time.Parse(time.RFC3339, event.End.DateTime)
-	oldStartTime, _ := time.Parse(time.RFC3339, event.Start.DateTime)
+	oldEndTime, _ := GetEventEnd(event, startTime.Location())
+	oldStartTime, _ := GetEventStart(event, startTime.Location())
 	duration := oldEndTime.Sub(oldStartTime)
 
-	event.Start.DateTime = startTime.Format(time.RFC3339)
-	event.End.DateTime = startTime.Add(duration).Format(time.RFC3339)
+	isAllDay := IsAllDay(event)
+	event = cleanEventForInsert(event)
+	if isAllDay {
+		event.Start, event.End = movedAllDayDates(oldStartTime, oldEndTime, startTime)
+	} else {
+		event.Start.DateTime = startTime.Format(time.RFC3339)
+		event.End.DateTime = startTime.Add(duration).Format(time.RFC3339)
+	}
 
-	call := srv.Events.Insert("primary", event)
-	event, err = call.Do()
+	_, err = sendNewActivity(ctx, *event, srv)
 	// Todo check if it becomes the current event or not ?
 	return err
 }
 
+// ResizeActivity : Changes the start and end time of the activity given in parameters, which is updated too.
+// Only the times are sent, and only if the event didn't change meanwhile : returns a *ConflictError otherwise.
+// Also give a pointer the the calendar service in order to send the api.
+func ResizeActivity(ctx context.Context, activity *calendar.Event, beginTime time.Time, endTime time.Time, srv *calendar.Service) (err error) {
+	patch := &calendar.Event{Start: timedDate(beginTime), End: timedDate(endTime)}
+	after, err := patchActivity(ctx, activity, patch, srv)
+	if err != nil {
+		return err
+	}
+	activity.Start = patch.Start
+	activity.End = patch.End
+	activity.Etag = after.Etag
+	return nil
+}
+
+// CopyActivityBetween : Inserts a copy of the activity given in parameters, with all its fields
+// (color, description, kind..), from the begin time to the end time given in parameters.
+// It will return, if it succeeds, the event created, and an error code in case it fails.
+func CopyActivityBetween(ctx context.Context, activity *calendar.Event, beginTime time.Time, endTime time.Time, srv *calendar.Service) (calendar.Event, error) {
+	newEvent := cleanEventForInsert(activity)
+	// The copy is a standalone event, even when copying an occurrence
+	newEvent.RecurringEventId = ""
+	newEvent.OriginalStartTime = nil
+	newEvent.Recurrence = nil
+	newEvent.Start = timedDate(beginTime)
+	newEvent.End = timedDate(endTime)
+	return sendNewActivity(ctx, *newEvent, srv)
+}
+
 // RenameActivity : Renames the activity given in parameters with the text parameter
+// Only the name is sent, and only if the event didn't change meanwhile : returns a *ConflictError otherwise.
 // Also give a pointer the the calendar service in order to send the api.
-func RenameActivity(activity *calendar.Event, text string, srv *calendar.Service) (err error) {
+func RenameActivity(ctx context.Context, activity *calendar.Event, text string, srv *calendar.Service) (err error) {
+	after, err := patchActivity(ctx, activity, &calendar.Event{Summary: text}, srv)
+	if err != nil {
+		return err
+	}
 	activity.Summary = text
-	call := srv.Events.Update("primary", activity.Id, activity)
-	_, err = call.Do()
-	return err
+	activity.Etag = after.Etag
+	return nil
 }
 
 // RenameActivityByID : Renames the activity given in parameters with the text parameter
 // Also give a pointer the the calendar service in order to send the api.
-func RenameActivityByID(eventID string, text string, srv *calendar.Service) (err error) {
-	request := srv.Events.Get("primary", eventID)
-	event, err := request.Do()
+func RenameActivityByID(ctx context.Context, eventID string, text string, srv *calendar.Service) (err error) {
+	event, err := getEvent(ctx, eventID, srv)
+	if err != nil {
+		return err
+	}
 
-	event.Summary = text
-	call := srv.Events.Update("primary", eventID, event)
-	_, err = call.Do()
+	_, err = patchActivity(ctx, event, &calendar.Event{Summary: text}, srv)
 	return err
 }
 
-// GetActivitiesBetweenDates Retrieve a Events* list of events which occurs between the dates given in parameters (in format RFC3339)
+// UpdateActivityFromID : Updates the name, the color and the start and end time of the activity
+// related to the id given in parameters.
 // Also give a pointer the the calendar service in order to send the api.
-func GetActivitiesBetweenDates(beginDate string, endDate string, srv *calendar.Service) (cals *calendar.Events, err error) {
+func UpdateActivityFromID(ctx context.Context, eventID string, name string, color string, beginTime time.Time, endTime time.Time, srv *calendar.Service) (err error) {
+	event, err := getEvent(ctx, eventID, srv)
+	if err != nil {
+		return err
+	}
+	patch := &calendar.Event{
+		Summary: name,
+		Start:   &calendar.EventDateTime{DateTime: beginTime.Format(time.RFC3339)},
+		End:     &calendar.EventDateTime{DateTime: endTime.Format(time.RFC3339)},
+	}
+	patch.ColorId, _ = GetColorIDFromColorName(color)
+	// An empty color id has to be sent too, to go back to the default color
+	patch.ForceSendFields = []string{"ColorId"}
+	_, err = patchActivity(ctx, event, patch, srv)
+	return err
+}
 
-	events, err := srv.Events.List("primary").ShowDeleted(false).
-		SingleEvents(true).TimeMin(beginDate).TimeMax(endDate).MaxResults(512).OrderBy("startTime").Do()
-	return events, err
+// GetActivitiesBetweenDates Retrieve a Events* list of all the events which occurs between the dates given in parameters
+// (in format RFC3339), whatever the length of the range. Use NewEventIterator to go through long ranges without keeping them.
+// Also give a pointer the the calendar service in order to send the api.
+func GetActivitiesBetweenDates(ctx context.Context, beginDate string, endDate string, srv *calendar.Service) (cals *calendar.Events, err error) {
+	begin, err := time.Parse(time.RFC3339, beginDate)
+	if err != nil {
+		return nil, err
+	}
+	end, err := time.Parse(time.RFC3339, endDate)
+	if err != nil {
+		return nil, err
+	}
+	cals = &calendar.Events{}
+	it := NewEventIterator(ctx, begin, end, srv)
+	defer it.Close()
+	for it.Next() {
+		cals.Items = append(cals.Items, it.Event())
+	}
+	if it.Err() != nil {
+		return nil, it.Err()
+	}
+	return cals, nil
+}
+
+// GetBusyPeriods Retrieve the periods where the calendars given in parameters are busy, between the dates given
+// in parameters (in format RFC3339), with the FreeBusy api. "primary" is the calendar of the user.
+// Also give a pointer the the calendar service in order to send the api.
+func GetBusyPeriods(ctx context.Context, calendarIDs []string, beginDate string, endDate string, srv *calendar.Service) (periods []*calendar.TimePeriod, err error) {
+	request := calendar.FreeBusyRequest{TimeMin: beginDate, TimeMax: endDate}
+	for _, id := range calendarIDs {
+		request.Items = append(request.Items, &calendar.FreeBusyRequestItem{Id: id})
+	}
+	var response *calendar.FreeBusyResponse
+	err = retry(ctx, "query busy periods", true, func(ctx context.Context) (err error) {
+		response, err = srv.Freebusy.Query(&request).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for id, cal := range response.Calendars {
+		if len(cal.Errors) > 0 {
+			return nil, errors.New("cannot get the busy periods of calendar '" + id + "' : " + cal.Errors[0].Reason)
+		}
+		periods = append(periods, cal.Busy...)
+	}
+	return periods, nil
+}
+
+// GetEventStart returns the start of the event, in the timezone given in parameters
+// whatever the timezone the event was logged in
+func GetEventStart(event *calendar.Event, location *time.Location) (time.Time, error) {
+	return parseEventDateTime(event.Start, location)
+}
+
+// GetEventEnd returns the end of the event, in the timezone given in parameters
+func GetEventEnd(event *calendar.Event, location *time.Location) (time.Time, error) {
+	return parseEventDateTime(event.End, location)
+}
+
+// parseEventDateTime parses the date of an event in the timezone given in parameters.
+// All day events start and end at midnight of that timezone
+func parseEventDateTime(date *calendar.EventDateTime, location *time.Location) (time.Time, error) {
+	if date == nil {
+		return time.Time{}, errors.New("The event has no date")
+	}
+	if date.DateTime == "" && date.Date != "" {
+		return time.ParseInLocation("2006-01-02", date.Date, location)
+	}
+	t, err := time.Parse(time.RFC3339, date.DateTime)
+	return t.In(location), err
 }
 
 // GetDuration Retrieve the duration (now - startTime) of current event
 func GetDuration(activity *calendar.Event) (string, error) {
 
-	startTime, err := time.Parse(time.RFC3339, activity.Start.DateTime)
+	startTime, err := GetEventStart(activity, time.Local)
 	if err != nil {
 		return "", err
 	}
@@ -226,14 +482,11 @@ func GetColorNameFromColorID(colorID string) (colorName string, err error) {
 
 // GetLastEvent function gets the last event we set on google agenda today, in
 // order to ask the user if he's still doing that task or not
-// TODO : Use the newer getActivitiesBetweenDates instead
-func GetLastEvent(srv *calendar.Service) (calendar.Event, error) {
+func GetLastEvent(ctx context.Context, srv *calendar.Service) (calendar.Event, error) {
 
 	var selectedEvent calendar.Event
 
-	t := time.Now().Format(time.RFC3339)
-	events, err := srv.Events.List("primary").ShowDeleted(false).
-		SingleEvents(true).TimeMin(time.Now().Add(-12 * time.Hour).Format(time.RFC3339)).TimeMax(t).MaxResults(128).OrderBy("startTime").Do()
+	events, err := GetActivitiesBetweenDates(ctx, time.Now().Add(-12*time.Hour).Format(time.RFC3339), time.Now().Format(time.RFC3339), srv)
 	if err != nil {
 		//displayError(ctx, "ERROR : "+err.Error())
 		return selectedEvent, err
@@ -250,26 +503,29 @@ func GetLastEvent(srv *calendar.Service) (calendar.Event, error) {
 	return selectedEvent, nil
 }
 
-//GetStartDateForEventID returns the date of a event given its ID
-func GetStartDateForEventID(ID string, srv *calendar.Service) (time.Time, error) {
-	request := srv.Events.Get("primary", ID)
-	event, err := request.Do()
-	date, _ := time.Parse(time.RFC3339, event.Start.DateTime)
-	return date, err
+//GetStartDateForEventID returns the date of a event given its ID, in the timezone given in parameters
+func GetStartDateForEventID(ctx context.Context, ID string, location *time.Location, srv *calendar.Service) (time.Time, error) {
+	event, err := getEvent(ctx, ID, srv)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return GetEventStart(event, location)
 }
 
 //GetColorNameForEventID returns the color name of a event given its ID
-func GetColorNameForEventID(ID string, srv *calendar.Service) (string, error) {
-	request := srv.Events.Get("primary", ID)
-	event, err := request.Do()
-	name, err := GetColorNameFromColorID(event.ColorId)
-	return name, err
-}
-
-//GetEndDateForEventID returns the end date of a event given its ID
-func GetEndDateForEventID(ID string, srv *calendar.Service) (time.Time, error) {
-	request := srv.Events.Get("primary", ID)
-	event, err := request.Do()
-	date, _ := time.Parse(time.RFC3339, event.End.DateTime)
-	return date, err
+func GetColorNameForEventID(ctx context.Context, ID string, srv *calendar.Service) (string, error) {
+	event, err := getEvent(ctx, ID, srv)
+	if err != nil {
+		return "", err
+	}
+	return GetColorNameFromColorID(event.ColorId)
+}
+
+//GetEndDateForEventID returns the end date of a event given its ID, in the timezone given in parameters
+func GetEndDateForEventID(ctx context.Context, ID string, location *time.Location, srv *calendar.Service) (time.Time, error) {
+	event, err := getEvent(ctx, ID, srv)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return GetEventEnd(event, location)
 }
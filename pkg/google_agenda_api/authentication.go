@@ -24,53 +24,283 @@ SOFTWARE.
 package google_agenda_api
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
-	"os/user"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
 
-	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
 )
 
+// Dir is the folder holding the credentials, the token and the state (journal, outbox, cache) of the calendar.
+// It has to be set before connecting, gogenda uses the folder of the profile
+var Dir string
+
+// ManualAuth is set on machines without a browser : the user opens the link elsewhere and pastes back
+// the address the browser was sent to, instead of gogenda receiving it on a local server
+var ManualAuth = false
+
+// authTimeout is how long gogenda waits for the user to allow it in the browser
+const authTimeout = 5 * time.Minute
+
+// OpenBrowser opens the url in the browser of the user. It can be replaced, to authorize from somewhere else
+var OpenBrowser = func(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
+
 // Retrieve a token, saves the token, then returns the generated client.
-func getClient(config *oauth2.Config) *http.Client {
-	// The file token.json stores the user's access and refresh tokens, and is
-	// created automatically when the authorization flow completes for the first
-	// time.
+func getClient(ctx context.Context, config *oauth2.Config) (*http.Client, error) {
+	// The store keeps the user's access and refresh tokens. They are saved
+	// automatically when the authorization flow completes for the first
+	// time, and each time the access token is refreshed.
+	store, err := getTokenStore()
+	if err != nil {
+		return nil, err
+	}
+	tok, err := loadToken(store)
+	if err == errNoToken {
+		tok, err = login(ctx, config, store)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return oauth2.NewClient(context.Background(), newPersistingTokenSource(config, tok, store)), nil
+}
+
+// loadToken loads the token from the store. A token saved in token.json before another storage was chosen
+// is moved to it
+func loadToken(store tokenStore) (*oauth2.Token, error) {
+	tok, err := store.load()
+	if err != errNoToken {
+		return tok, err
+	}
+	plain := fileTokenStore{path: Dir + "/token.json"}
+	if _, isFile := store.(fileTokenStore); isFile {
+		return nil, errNoToken
+	}
+	tok, err = plain.load()
+	if err != nil {
+		return nil, err
+	}
+	err = store.save(tok)
+	if err != nil {
+		return nil, err
+	}
+	return tok, plain.remove()
+}
+
+// login asks the user to allow gogenda to access the calendar, and saves the token
+func login(ctx context.Context, config *oauth2.Config, store tokenStore) (tok *oauth2.Token, err error) {
+	if ManualAuth {
+		tok, err = getTokenFromPastedURL(ctx, config)
+	} else {
+		tok, err = getTokenFromWeb(ctx, config)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Saving credential file to: %s\n", store)
+	err = store.save(tok)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to cache oauth token: %v", err)
+	}
+	return tok, nil
+}
+
+// persistingTokenSource gives the tokens of the calendar, and saves them each time they are refreshed,
+// so that the next launch doesn't have to refresh them again
+type persistingTokenSource struct {
+	mutex  sync.Mutex
+	source oauth2.TokenSource
+	store  tokenStore
+	last   *oauth2.Token
+}
+
+// newPersistingTokenSource returns the token source refreshing the token given, and saving it in the store
+func newPersistingTokenSource(config *oauth2.Config, tok *oauth2.Token, store tokenStore) oauth2.TokenSource {
+	return &persistingTokenSource{source: config.TokenSource(context.Background(), tok), store: store, last: tok}
+}
 
-	usr, _ := user.Current()
-	dir := usr.HomeDir
-	tokFile := dir + "/.gogenda/token.json"
-	tok, err := tokenFromFile(tokFile)
+// Token returns a valid token, refreshing it if needed
+func (source *persistingTokenSource) Token() (*oauth2.Token, error) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	tok, err := source.source.Token()
 	if err != nil {
-		tok = getTokenFromWeb(config)
-		saveToken(tokFile, tok)
+		return nil, err
+	}
+	if tok.AccessToken != source.last.AccessToken {
+		// Failing to save it only means it will be refreshed again next time
+		source.store.save(tok)
+		source.last = tok
 	}
-	return config.Client(context.Background(), tok)
+	return tok, nil
 }
 
-// Request a token from the web, then returns the retrieved token.
-func getTokenFromWeb(config *oauth2.Config) *oauth2.Token {
-	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-	fmt.Printf("Go to the following link in your browser then type the "+
-		"authorization code: \n%v\n", authURL)
+// authRequest holds the secrets of one authorization : the state sent back by google with the code,
+// and the PKCE verifier proving the code is exchanged by the one who asked it
+type authRequest struct {
+	state    string
+	verifier string
+}
 
-	var authCode string
-	if _, err := fmt.Scan(&authCode); err != nil {
-		log.Fatalf("Unable to read authorization code: %v", err)
+// randomString returns a random url safe string
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
 	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	tok, err := config.Exchange(context.TODO(), authCode)
+// newAuthRequest generates the secrets of a new authorization
+func newAuthRequest() (request authRequest, err error) {
+	request.state, err = randomString()
 	if err != nil {
-		log.Fatalf("Unable to retrieve token from web: %v", err)
+		return request, err
 	}
-	return tok
+	request.verifier, err = randomString()
+	return request, err
+}
+
+// authURL returns the link where the user allows gogenda to access the calendar
+func (request authRequest) authURL(config *oauth2.Config) string {
+	challenge := sha256.Sum256([]byte(request.verifier))
+	return config.AuthCodeURL(request.state, oauth2.AccessTypeOffline,
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"))
+}
+
+// code checks the parameters google sent back to the redirect url, and returns the authorization code
+func (request authRequest) code(query url.Values) (string, error) {
+	if query.Get("state") != request.state {
+		return "", errors.New("the answer does not match the authorization asked, try again")
+	}
+	if query.Get("error") != "" {
+		return "", errors.New("the authorization has been refused : " + query.Get("error"))
+	}
+	if query.Get("code") == "" {
+		return "", errors.New("the answer holds no authorization code")
+	}
+	return query.Get("code"), nil
+}
+
+// exchange gets the token of the authorization code
+func (request authRequest) exchange(ctx context.Context, config *oauth2.Config, code string) (*oauth2.Token, error) {
+	tok, err := config.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", request.verifier))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve token from web: %v", err)
+	}
+	return tok, nil
+}
+
+// Request a token from the web : google redirects the browser to a temporary server on 127.0.0.1
+// with the authorization code, then returns the retrieved token.
+func getTokenFromWeb(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	request, err := newAuthRequest()
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("Unable to start the local authorization server, try with -no-browser: %v", err)
+	}
+	redirectConfig := *config
+	redirectConfig.RedirectURL = "http://" + listener.Addr().String()
+
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			// Like the favicon the browser asks for
+			http.NotFound(w, r)
+			return
+		}
+		code, err := request.code(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			select {
+			case errs <- err:
+			default:
+			}
+			return
+		}
+		fmt.Fprintln(w, "Gogenda can access your calendar, you can close this tab.")
+		select {
+		case codes <- code:
+		default:
+		}
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	authURL := request.authURL(&redirectConfig)
+	fmt.Printf("Go to the following link in your browser to allow gogenda "+
+		"to access your calendar: \n%v\n", authURL)
+	OpenBrowser(authURL)
+
+	select {
+	case code := <-codes:
+		return request.exchange(ctx, &redirectConfig, code)
+	case err = <-errs:
+		return nil, err
+	case <-time.After(authTimeout):
+		return nil, errors.New("no answer from the browser, try again, or with -no-browser")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Request a token from the web without a local server : the user pastes the address
+// the browser has been redirected to, then returns the retrieved token.
+func getTokenFromPastedURL(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	request, err := newAuthRequest()
+	if err != nil {
+		return nil, err
+	}
+	redirectConfig := *config
+	// Nothing listens there : the browser shows an error page, with the code in its address
+	redirectConfig.RedirectURL = "http://127.0.0.1"
+	fmt.Printf("Go to the following link in your browser, allow gogenda to access your calendar, "+
+		"then type the address of the page it fails to load: \n%v\n", request.authURL(&redirectConfig))
+
+	var pasted string
+	if _, err := fmt.Scan(&pasted); err != nil {
+		return nil, fmt.Errorf("Unable to read the address: %v", err)
+	}
+	redirected, err := url.Parse(pasted)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read the address: %v", err)
+	}
+	code, err := request.code(redirected.Query())
+	if err != nil {
+		return nil, err
+	}
+	return request.exchange(ctx, &redirectConfig, code)
 }
 
 // Retrieves a token from a local file.
@@ -86,38 +316,187 @@ func tokenFromFile(file string) (*oauth2.Token, error) {
 }
 
 // Saves a token to a file path.
-func saveToken(path string, token *oauth2.Token) {
-
-	fmt.Printf("Saving credential file to: %s\n", path)
+func saveToken(path string, token *oauth2.Token) error {
 	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
-		log.Fatalf("Unable to cache oauth token: %v", err)
+		return err
 	}
 	defer f.Close()
-	json.NewEncoder(f).Encode(token)
+	return json.NewEncoder(f).Encode(token)
+}
+
+// loadCredentials reads credentials.json, the keys of the application registered to access google agenda
+func loadCredentials() (*oauth2.Config, error) {
+	if Dir == "" {
+		return nil, errors.New("No folder set for the credentials and the state of the calendar")
+	}
+	b, err := ioutil.ReadFile(Dir + "/credentials.json")
+	if os.IsNotExist(err) {
+		return nil, errors.New("No credentials.json in " + Dir + " : register an application to access google agenda " +
+			"and put its credentials.json there (see the README)")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read client secret file: %v", err)
+	}
+	config, err := google.ConfigFromJSON(b, calendar.CalendarScope)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse client secret file to config: %v", err)
+	}
+	return config, nil
 }
 
 // Connect to the google agenda endpoint. Will set up automatically the credentials if they dont exist yet
 // return  Calendar service pointer to have access to the calendar
-func Connect() (*calendar.Service, error) {
+func Connect(ctx context.Context) (*calendar.Service, error) {
+	config, err := loadCredentials()
+	if err != nil {
+		return nil, err
+	}
+	client, err := getClient(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	srv, err := calendar.New(client)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to retrieve Calendar client: %v", err)
+	}
+	return srv, err
+}
 
-	usr, _ := user.Current()
-	userDir := usr.HomeDir
+// Endpoints of google describing and revoking the tokens
+var (
+	tokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+	revokeURL    = "https://oauth2.googleapis.com/revoke"
+)
 
-	b, err := ioutil.ReadFile(userDir + "/.gogenda/credentials.json")
+// AuthStatus describes what gogenda is allowed to do on the calendar
+type AuthStatus struct {
+	// Account is the google account, as the id of its primary calendar
+	Account string
+	// Scopes are the permissions given to gogenda
+	Scopes []string
+	// Expiry is when the access token expires, it is refreshed automatically
+	Expiry time.Time
+	// CanRefresh is false when the token cannot be refreshed : gogenda will have to be allowed again
+	CanRefresh bool
+	// Storage describes where the token is kept
+	Storage string
+}
+
+// Login allows gogenda to access the calendar, replacing the token saved if there is one
+func Login(ctx context.Context) error {
+	config, err := loadCredentials()
+	if err != nil {
+		return err
+	}
+	store, err := getTokenStore()
 	if err != nil {
-		log.Fatalf("Unable to read client secret file: %v", err)
+		return err
 	}
+	_, err = login(ctx, config, store)
+	return err
+}
 
-	config, err := google.ConfigFromJSON(b, calendar.CalendarScope)
+// sendTokenRequest sends a request to an endpoint of google about the tokens, and decodes its answer in result if not nil
+func sendTokenRequest(ctx context.Context, operation string, method string, endpoint string, form url.Values, result interface{}) error {
+	return retry(ctx, operation, true, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		if method == http.MethodGet {
+			req.URL.RawQuery = form.Encode()
+			req.Body = http.NoBody
+		} else {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if err = googleapi.CheckResponse(resp); err != nil {
+			return err
+		}
+		if result == nil {
+			return nil
+		}
+		return json.NewDecoder(resp.Body).Decode(result)
+	})
+}
+
+// GetAuthStatus returns the account, the permissions and the expiry of the token, refreshing it if needed
+func GetAuthStatus(ctx context.Context) (status AuthStatus, err error) {
+	config, err := loadCredentials()
 	if err != nil {
-		log.Fatalf("Unable to parse client secret file to config: %v", err)
+		return status, err
 	}
-	client := getClient(config)
+	store, err := getTokenStore()
+	if err != nil {
+		return status, err
+	}
+	status.Storage = store.String()
+	tok, err := loadToken(store)
+	if err != nil {
+		return status, err
+	}
+	source := newPersistingTokenSource(config, tok, store)
+	tok, err = source.Token()
+	if err != nil {
+		return status, newError("refresh token", err)
+	}
+	status.Expiry = tok.Expiry
+	status.CanRefresh = tok.RefreshToken != ""
 
-	srv, err := calendar.New(client)
+	var info struct {
+		Scope string `json:"scope"`
+	}
+	err = sendTokenRequest(ctx, "get token info", http.MethodGet, tokenInfoURL, url.Values{"access_token": {tok.AccessToken}}, &info)
 	if err != nil {
-		log.Fatalf("Unable to retrieve Calendar client: %v", err)
+		return status, err
 	}
-	return srv, err
+	status.Scopes = strings.Fields(info.Scope)
+
+	srv, err := calendar.New(oauth2.NewClient(ctx, source))
+	if err != nil {
+		return status, err
+	}
+	var cal *calendar.Calendar
+	err = retry(ctx, "get calendar", true, func(ctx context.Context) (err error) {
+		cal, err = srv.Calendars.Get("primary").Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return status, err
+	}
+	status.Account = cal.Id
+	return status, nil
+}
+
+// Logout revokes the token, so that gogenda cannot access the calendar anymore, and deletes it.
+// The token is deleted even if google could not be asked to revoke it
+func Logout(ctx context.Context) error {
+	store, err := getTokenStore()
+	if err != nil {
+		return err
+	}
+	tok, err := loadToken(store)
+	if err != nil {
+		return err
+	}
+	revoked := tok.RefreshToken
+	if revoked == "" {
+		revoked = tok.AccessToken
+	}
+	errRevoke := sendTokenRequest(ctx, "revoke token", http.MethodPost, revokeURL, url.Values{"token": {revoked}}, nil)
+	err = store.remove()
+	if err != nil {
+		return err
+	}
+	if errRevoke != nil {
+		return errors.New("the token has been deleted but could not be revoked, remove gogenda from " +
+			"https://myaccount.google.com/permissions : " + errRevoke.Error())
+	}
+	return nil
 }
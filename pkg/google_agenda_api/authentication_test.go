@@ -0,0 +1,163 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package google_agenda_api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestGetTokenFromWeb(t *testing.T) {
+	tests := []struct {
+		name string
+		// answer gives the parameters the browser is redirected with, from the ones of the authorization link
+		answer    func(auth url.Values) url.Values
+		wantErr   string
+		wantToken bool
+	}{
+		{
+			name: "the code is exchanged with the verifier",
+			answer: func(auth url.Values) url.Values {
+				return url.Values{"state": {auth.Get("state")}, "code": {"the-code"}}
+			},
+			wantToken: true,
+		},
+		{
+			name: "an answer to another authorization is rejected",
+			answer: func(auth url.Values) url.Values {
+				return url.Values{"state": {"forged"}, "code": {"the-code"}}
+			},
+			wantErr: "does not match",
+		},
+		{
+			name: "an answer without state is rejected",
+			answer: func(auth url.Values) url.Values {
+				return url.Values{"code": {"the-code"}}
+			},
+			wantErr: "does not match",
+		},
+		{
+			name: "the user refused",
+			answer: func(auth url.Values) url.Values {
+				return url.Values{"state": {auth.Get("state")}, "error": {"access_denied"}}
+			},
+			wantErr: "access_denied",
+		},
+		{
+			name: "an answer without code",
+			answer: func(auth url.Values) url.Values {
+				return url.Values{"state": {auth.Get("state")}}
+			},
+			wantErr: "no authorization code",
+		},
+	}
+	defer func(openBrowser func(string) error) { OpenBrowser = openBrowser }(OpenBrowser)
+
+	for _, test := range tests {
+		var challenge string
+		var exchanges []url.Values
+		tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			exchanges = append(exchanges, r.PostForm)
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"access_token":"access","token_type":"Bearer","refresh_token":"refresh","expires_in":3600}`)
+		}))
+		config := &oauth2.Config{
+			ClientID: "gogenda",
+			Endpoint: oauth2.Endpoint{AuthURL: "https://accounts.example.com/auth", TokenURL: tokenServer.URL},
+		}
+
+		var redirectStatus int
+		OpenBrowser = func(link string) error {
+			// Act as google : check the link, then send the browser back to gogenda
+			authURL, err := url.Parse(link)
+			if err != nil {
+				return err
+			}
+			auth := authURL.Query()
+			challenge = auth.Get("code_challenge")
+			if auth.Get("code_challenge_method") != "S256" || challenge == "" {
+				t.Errorf("%s : the link has no PKCE challenge : %s", test.name, link)
+			}
+			if !strings.HasPrefix(auth.Get("redirect_uri"), "http://127.0.0.1:") {
+				t.Errorf("%s : the link does not redirect to the local server : %s", test.name, link)
+			}
+			response, err := http.Get(auth.Get("redirect_uri") + "/?" + test.answer(auth).Encode())
+			if err != nil {
+				return err
+			}
+			response.Body.Close()
+			redirectStatus = response.StatusCode
+			return nil
+		}
+
+		tok, err := getTokenFromWeb(context.Background(), config)
+		tokenServer.Close()
+
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%s : got error %v, want one about %q", test.name, err, test.wantErr)
+			}
+			if redirectStatus != http.StatusBadRequest {
+				t.Errorf("%s : the browser got status %d, want %d", test.name, redirectStatus, http.StatusBadRequest)
+			}
+			if len(exchanges) != 0 {
+				t.Errorf("%s : the code should not have been exchanged", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s : unexpected error %v", test.name, err)
+			continue
+		}
+		if tok.AccessToken != "access" || tok.RefreshToken != "refresh" {
+			t.Errorf("%s : got token %+v", test.name, tok)
+		}
+		if redirectStatus != http.StatusOK {
+			t.Errorf("%s : the browser got status %d, want %d", test.name, redirectStatus, http.StatusOK)
+		}
+		if len(exchanges) != 1 {
+			t.Errorf("%s : the code was exchanged %d times, want once", test.name, len(exchanges))
+			continue
+		}
+		exchange := exchanges[0]
+		if exchange.Get("code") != "the-code" || exchange.Get("grant_type") != "authorization_code" {
+			t.Errorf("%s : wrong exchange %v", test.name, exchange)
+		}
+		verifier := exchange.Get("code_verifier")
+		sum := sha256.Sum256([]byte(verifier))
+		if verifier == "" || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			t.Errorf("%s : the verifier %q does not match the challenge %q", test.name, verifier, challenge)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package google_agenda_api
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// Operations of a batch
+const (
+	BatchInsert = "insert"
+	BatchPatch  = "patch"
+	BatchDelete = "delete"
+)
+
+// batchWorkers is the number of requests of a batch sent at the same time
+const batchWorkers = 4
+
+// RequestsPerSecond is the maximum number of requests a batch starts each second, to stay within the quota of google agenda
+var RequestsPerSecond = 5
+
+// BatchItem is one mutation of a batch
+type BatchItem struct {
+	// Operation is BatchInsert, BatchPatch or BatchDelete
+	Operation string
+	// Loaded is the event to patch or delete, as gogenda loaded it
+	Loaded *calendar.Event
+	// Event is the event to insert, or the fields to patch
+	Event *calendar.Event
+}
+
+// BatchResult is what happened to an item of a batch
+type BatchResult struct {
+	Item BatchItem
+	// Event is the event on the calendar after the mutation, nil for a delete
+	Event *calendar.Event
+	// Err is why the mutation failed, a *ConflictError if the event changed on the calendar meanwhile
+	Err error
+}
+
+// InsertLoggedItem returns the item inserting a logged activity, like InsertActivity
+func InsertLoggedItem(name string, color string, beginTime time.Time, endTime time.Time) BatchItem {
+	event := newActivity(name, color, timedDate(beginTime), timedDate(endTime), map[string]string{kindProperty: KindLogged})
+	return BatchItem{Operation: BatchInsert, Event: &event}
+}
+
+// InsertPlannedItem returns the item inserting a one-off planned activity, like InsertPlannedActivity
+func InsertPlannedItem(name string, color string, beginTime time.Time, endTime time.Time) BatchItem {
+	event := newActivity(name, color, timedDate(beginTime), timedDate(endTime), map[string]string{kindProperty: KindPlanned})
+	return BatchItem{Operation: BatchInsert, Event: &event}
+}
+
+// UpdateItem returns the item updating the name, the color and the start and end time of the event,
+// like UpdateActivityFromID but without loading it again
+func UpdateItem(event *calendar.Event, name string, color string, beginTime time.Time, endTime time.Time) BatchItem {
+	patch := &calendar.Event{Summary: name, Start: timedDate(beginTime), End: timedDate(endTime)}
+	patch.ColorId, _ = GetColorIDFromColorName(color)
+	// An empty color id has to be sent too, to go back to the default color
+	patch.ForceSendFields = []string{"ColorId"}
+	return BatchItem{Operation: BatchPatch, Loaded: event, Event: patch}
+}
+
+// DeleteItem returns the item deleting the event
+func DeleteItem(event *calendar.Event) BatchItem {
+	return BatchItem{Operation: BatchDelete, Loaded: event}
+}
+
+// RunBatch sends the mutations of the batch, a few at the same time and no more than RequestsPerSecond,
+// with the same journal, conflict and offline handling as the mutations done one by one.
+// Returns the result of each item, in the same order. An item failing doesn't stop the others,
+// but the items not started yet when the context is canceled fail with its error
+func RunBatch(ctx context.Context, items []BatchItem, srv *calendar.Service) []BatchResult {
+	results := make([]BatchResult, len(items))
+	indexes := make(chan int)
+	done := make(chan struct{})
+	rate := RequestsPerSecond
+	if rate < 1 {
+		rate = 1
+	}
+	limiter := time.NewTicker(time.Second / time.Duration(rate))
+	defer limiter.Stop()
+
+	for worker := 0; worker < batchWorkers; worker++ {
+		go func() {
+			for i := range indexes {
+				results[i] = runBatchItem(ctx, items[i], srv)
+			}
+			done <- struct{}{}
+		}()
+	}
+	for i := range items {
+		select {
+		case <-limiter.C:
+			indexes <- i
+		case <-ctx.Done():
+			results[i] = BatchResult{Item: items[i], Err: newError(items[i].Operation+" event", ctx.Err())}
+		}
+	}
+	close(indexes)
+	for worker := 0; worker < batchWorkers; worker++ {
+		<-done
+	}
+	return results
+}
+
+// runBatchItem sends one mutation of a batch
+func runBatchItem(ctx context.Context, item BatchItem, srv *calendar.Service) (result BatchResult) {
+	result.Item = item
+	switch item.Operation {
+	case BatchInsert:
+		var event calendar.Event
+		event, result.Err = sendNewActivity(ctx, *copyEvent(item.Event), srv)
+		if result.Err == nil {
+			result.Event = &event
+		}
+	case BatchPatch:
+		result.Event, result.Err = patchActivity(ctx, item.Loaded, item.Event, srv)
+	case BatchDelete:
+		result.Err = DeleteActivity(ctx, copyEvent(item.Loaded), srv)
+	}
+	return result
+}
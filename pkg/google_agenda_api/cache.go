@@ -0,0 +1,254 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package google_agenda_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// CacheEnabled is unset to always ask the events to the api instead of the local cache
+var CacheEnabled = true
+
+// The cache holds the events from cacheMonthsBefore months ago to cacheMonthsAfter months ahead of its first sync.
+// It is downloaded again once less than cacheMonthsLeft months are left ahead, the events outside are asked to the api
+const (
+	cacheMonthsBefore = 12
+	cacheMonthsAfter  = 3
+	cacheMonthsLeft   = 1
+)
+
+// errOutsideCache is returned when the events asked are not all in the window of the cache
+var errOutsideCache = errors.New("the dates are outside of the cache")
+
+// eventCache is a local copy of the events of the calendar around the date of its first sync,
+// kept current with the sync token of the api
+type eventCache struct {
+	// SyncToken gives the changes done on the calendar since the last sync
+	SyncToken string `json:"syncToken"`
+	// LastSync is the date of the last sync that brought changes
+	LastSync time.Time `json:"lastSync"`
+	// WindowBegin and WindowEnd are the dates between which all the events are in the cache
+	WindowBegin time.Time `json:"windowBegin"`
+	WindowEnd   time.Time `json:"windowEnd"`
+	// Events are the events of the calendar by id
+	Events map[string]*calendar.Event `json:"events"`
+}
+
+// CacheStatus describes the local cache
+type CacheStatus struct {
+	// Path of the cache file
+	Path string
+	// NbEvents is the number of events in the cache
+	NbEvents int
+	// LastSync is the date of the last sync that brought changes, zero if the cache is empty
+	LastSync time.Time
+	// WindowBegin and WindowEnd are the dates between which all the events are in the cache
+	WindowBegin time.Time
+	WindowEnd   time.Time
+	// Size of the cache file, in bytes
+	Size int64
+}
+
+// cache is loaded once, and synced before each read
+var cache *eventCache
+var cacheMutex sync.Mutex
+
+// cacheDir returns the directory of the cache files
+func cacheDir() string {
+	return Dir + "/cache"
+}
+
+// cachePath returns the path of the cache of the events of the primary calendar
+func cachePath() string {
+	return cacheDir() + "/primary.json"
+}
+
+// loadCache loads the cache file, an empty cache if there is none or if it cannot be read
+func loadCache() *eventCache {
+	loaded := &eventCache{Events: make(map[string]*calendar.Event)}
+	f, err := os.Open(cachePath())
+	if err != nil {
+		return loaded
+	}
+	defer f.Close()
+	if json.NewDecoder(f).Decode(loaded) != nil || loaded.Events == nil {
+		return &eventCache{Events: make(map[string]*calendar.Event)}
+	}
+	return loaded
+}
+
+// saveCache writes the cache file
+func saveCache(toSave *eventCache) error {
+	err := os.MkdirAll(cacheDir(), 0700)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(cachePath(), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(toSave)
+}
+
+// isGone returns true when the api refuses a sync token that expired
+func isGone(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusGone
+}
+
+// syncCache gets the changes done on the calendar since the last sync, or the events of the window of the cache
+// for the first one. The cache is rebuilt from scratch when the api says the sync token expired,
+// or when its window is about to be over
+func syncCache(ctx context.Context, srv *calendar.Service) (err error) {
+	if cache == nil {
+		cache = loadCache()
+	}
+	changed := false
+	if cache.SyncToken != "" && time.Now().AddDate(0, cacheMonthsLeft, 0).After(cache.WindowEnd) {
+		cache = &eventCache{Events: make(map[string]*calendar.Event)}
+		changed = true
+	}
+	pageToken := ""
+	for {
+		call := srv.Events.List("primary").SingleEvents(true).MaxResults(pageSize)
+		if cache.SyncToken != "" {
+			call = call.SyncToken(cache.SyncToken)
+		} else {
+			// The first sync is bounded, the next ones give the changes done on any event
+			if pageToken == "" {
+				now := time.Now()
+				cache.WindowBegin = now.AddDate(0, -cacheMonthsBefore, 0)
+				cache.WindowEnd = now.AddDate(0, cacheMonthsAfter, 0)
+			}
+			call = call.TimeMin(cache.WindowBegin.Format(time.RFC3339)).TimeMax(cache.WindowEnd.Format(time.RFC3339))
+		}
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		page, err := listEvents(ctx, call)
+		if isGone(err) && cache.SyncToken != "" {
+			// Start again with a full sync
+			cache = &eventCache{Events: make(map[string]*calendar.Event)}
+			pageToken = ""
+			changed = true
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, event := range page.Items {
+			if event.Status == "cancelled" {
+				delete(cache.Events, event.Id)
+			} else {
+				cache.Events[event.Id] = event
+			}
+			changed = true
+		}
+		if page.NextPageToken == "" {
+			if page.NextSyncToken != cache.SyncToken {
+				cache.SyncToken = page.NextSyncToken
+				changed = true
+			}
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+	if changed {
+		cache.LastSync = time.Now()
+		// The cache can be rebuilt, failing to write it must not fail the read
+		saveCache(cache)
+	}
+	return nil
+}
+
+// cachedEventsBetween syncs the cache and returns the events that occur between the dates given in parameters,
+// with the changes waiting in the outbox, ordered by start time like the api does.
+// Returns errOutsideCache when the dates are not in the window of the cache
+func cachedEventsBetween(ctx context.Context, begin time.Time, end time.Time, srv *calendar.Service) (events []*calendar.Event, err error) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	err = syncCache(ctx, srv)
+	if err != nil && !(isOffline(err) && cache.SyncToken != "") {
+		return nil, err
+	}
+	if begin.Before(cache.WindowBegin) || end.After(cache.WindowEnd) {
+		return nil, errOutsideCache
+	}
+	// Offline, the events are the ones of the last sync
+	var all []*calendar.Event
+	for _, event := range cache.Events {
+		all = append(all, event)
+	}
+	for _, event := range withPendingEvents(all, begin, end) {
+		start, errStart := GetEventStart(event, time.Local)
+		stop, errStop := GetEventEnd(event, time.Local)
+		if errStart == nil && errStop == nil && start.Before(end) && stop.After(begin) {
+			events = append(events, event)
+		}
+	}
+	sortByStart(events)
+	return events, nil
+}
+
+// GetCacheStatus describes the local cache of the events
+func GetCacheStatus() (status CacheStatus, err error) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	status.Path = cachePath()
+	info, err := os.Stat(status.Path)
+	if os.IsNotExist(err) {
+		return status, nil
+	}
+	if err != nil {
+		return status, err
+	}
+	status.Size = info.Size()
+	loaded := loadCache()
+	status.NbEvents = len(loaded.Events)
+	status.LastSync = loaded.LastSync
+	status.WindowBegin, status.WindowEnd = loaded.WindowBegin, loaded.WindowEnd
+	return status, nil
+}
+
+// ClearCache removes the local cache of the events. It will be downloaded again on the next read
+func ClearCache() error {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+	cache = nil
+	err := os.Remove(cachePath())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,161 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package google_agenda_api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/option"
+)
+
+// fakeCalendar acts as the api of google agenda, and keeps the requests it got
+type fakeCalendar struct {
+	events []*calendar.Event
+	// queries are the parameters of the lists of events
+	queries []url.Values
+	// requests are the methods and paths of all the requests
+	requests []string
+	// offline drops the connection of the requests, as if the calendar could not be reached
+	offline bool
+	// statuses are the errors answered to the next requests, one per request
+	statuses []int
+}
+
+// newFakeCalendar starts a fake api with the events given in parameters, in a temporary gogenda folder.
+// Inserted events get the id "sent" followed by their number. Returns the service to call it and the function stopping it
+func newFakeCalendar(t *testing.T, events ...*calendar.Event) (*fakeCalendar, *calendar.Service, func()) {
+	fake := &fakeCalendar{events: events}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fake.offline {
+			panic(http.ErrAbortHandler)
+		}
+		fake.requests = append(fake.requests, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		if len(fake.statuses) > 0 {
+			status := fake.statuses[0]
+			fake.statuses = fake.statuses[1:]
+			w.WriteHeader(status)
+			fmt.Fprintf(w, `{"error":{"code":%d,"message":"%s"}}`, status, http.StatusText(status))
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/events"):
+			fake.queries = append(fake.queries, r.URL.Query())
+			json.NewEncoder(w).Encode(&calendar.Events{Items: fake.events, NextSyncToken: "next"})
+		case r.Method == http.MethodPost:
+			event := &calendar.Event{}
+			json.NewDecoder(r.Body).Decode(event)
+			event.Id = "sent" + strconv.Itoa(len(fake.requests))
+			fake.events = append(fake.events, event)
+			json.NewEncoder(w).Encode(event)
+		default:
+			id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+			json.NewEncoder(w).Encode(&calendar.Event{Id: id})
+		}
+	}))
+	srv, err := calendar.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "gogenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldDir := Dir
+	Dir = dir
+	cache = nil
+	return fake, srv, func() {
+		server.Close()
+		Dir = oldDir
+		cache = nil
+		os.RemoveAll(dir)
+	}
+}
+
+// timedEvent returns an event with the id given in parameters, lasting an hour from start
+func timedEvent(id string, start time.Time) *calendar.Event {
+	return &calendar.Event{Id: id, Start: timedDate(start), End: timedDate(start.Add(time.Hour))}
+}
+
+func TestCacheWindow(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	fake, srv, stop := newFakeCalendar(t, timedEvent("recent", now.Add(-2*time.Hour)))
+	defer stop()
+	ctx := context.Background()
+
+	// The first sync is bounded around now
+	events, err := cachedEventsBetween(ctx, now.AddDate(0, 0, -1), now, srv)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("first read of the cache = %v, %v, want the recent event", events, err)
+	}
+	first := fake.queries[0]
+	if first.Get("timeMin") == "" || first.Get("timeMax") == "" || first.Get("syncToken") != "" {
+		t.Errorf("the first sync is not bounded : %v", first)
+	}
+	timeMin, _ := time.Parse(time.RFC3339, first.Get("timeMin"))
+	timeMax, _ := time.Parse(time.RFC3339, first.Get("timeMax"))
+	if fromNow := timeMin.Sub(now.AddDate(0, -cacheMonthsBefore, 0)); fromNow < 0 || fromNow > time.Minute {
+		t.Errorf("the first sync is from %v, want %d months before %v", timeMin, cacheMonthsBefore, now)
+	}
+	if fromNow := timeMax.Sub(now.AddDate(0, cacheMonthsAfter, 0)); fromNow < 0 || fromNow > time.Minute {
+		t.Errorf("the first sync is to %v, want %d months after %v", timeMax, cacheMonthsAfter, now)
+	}
+
+	// The next ones give the changes, without bounds
+	_, err = cachedEventsBetween(ctx, now.AddDate(0, 0, -1), now, srv)
+	next := fake.queries[1]
+	if err != nil || next.Get("syncToken") != "next" || next.Get("timeMin") != "" || next.Get("timeMax") != "" {
+		t.Errorf("the next sync is %v, %v, want the changes since the sync token", next, err)
+	}
+
+	// The dates outside of the window are asked to the api
+	old := now.AddDate(-2, 0, 0)
+	fake.events = []*calendar.Event{timedEvent("old", old)}
+	_, err = cachedEventsBetween(ctx, old.AddDate(0, 0, -1), old.AddDate(0, 0, 1), srv)
+	if err != errOutsideCache {
+		t.Errorf("reading the cache two years ago gave %v, want %v", err, errOutsideCache)
+	}
+	it := NewEventIterator(ctx, old.AddDate(0, 0, -1), old.AddDate(0, 0, 1), srv)
+	defer it.Close()
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Event().Id)
+	}
+	last := fake.queries[len(fake.queries)-1]
+	if it.Err() != nil || len(ids) != 1 || ids[0] != "old" || last.Get("timeMin") != old.AddDate(0, 0, -1).Format(time.RFC3339) {
+		t.Errorf("iterating two years ago gave %v, %v with the query %v, want the old event from the api", ids, it.Err(), last)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package google_agenda_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// ConflictError is returned when an event has been modified on the calendar (on the phone, on the web..)
+// since gogenda loaded it, so that the change would silently overwrite the one done meanwhile
+type ConflictError struct {
+	// Loaded is the event as gogenda loaded it
+	Loaded *calendar.Event
+	// Remote is the event as it is now on the calendar
+	Remote *calendar.Event
+	// Patch holds the fields gogenda wanted to change
+	Patch *calendar.Event
+}
+
+func (conflict *ConflictError) Error() string {
+	return "the event '" + conflict.Loaded.Summary + "' has been modified on the calendar meanwhile"
+}
+
+// Changes lists the fields that have been modified on the calendar since the event was loaded
+// as "field : 'loaded value' -> 'remote value'"
+func (conflict *ConflictError) Changes() (changes []string) {
+	loaded := eventFields(conflict.Loaded)
+	remote := eventFields(conflict.Remote)
+	for _, field := range []string{"summary", "description", "location", "colorId", "start", "end", "status"} {
+		if loaded[field] != remote[field] {
+			changes = append(changes, field+" : '"+loaded[field]+"' -> '"+remote[field]+"'")
+		}
+	}
+	return changes
+}
+
+// eventFields returns the user visible fields of the event, as strings
+func eventFields(event *calendar.Event) map[string]string {
+	fields := map[string]string{
+		"summary":     event.Summary,
+		"description": event.Description,
+		"location":    event.Location,
+		"colorId":     event.ColorId,
+		"status":      event.Status,
+	}
+	if event.Start != nil {
+		fields["start"] = event.Start.DateTime + event.Start.Date
+	}
+	if event.End != nil {
+		fields["end"] = event.End.DateTime + event.End.Date
+	}
+	return fields
+}
+
+// applyPatch returns a copy of the event with the fields of the patch set
+func applyPatch(event *calendar.Event, patch *calendar.Event) *calendar.Event {
+	patched := copyEvent(event)
+	b, err := json.Marshal(patch)
+	if err == nil {
+		json.Unmarshal(b, patched)
+	}
+	return patched
+}
+
+// isPreconditionFailed checks if the error is google telling the etag doesn't match anymore
+func isPreconditionFailed(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed
+}
+
+// patchActivity sends only the fields of the patch, and only if the event didn't change on the calendar
+// since it was loaded (its etag still matches). The change is recorded in the journal.
+// Returns a *ConflictError if the event changed meanwhile. Offline, the change is kept in the outbox
+func patchActivity(ctx context.Context, loaded *calendar.Event, patch *calendar.Event, srv *calendar.Service) (*calendar.Event, error) {
+	if IsProvisionalID(loaded.Id) {
+		// The event itself is still waiting to be sent
+		return queuePatch(loaded, patch)
+	}
+	after, err := sendPatch(ctx, loaded, patch, srv)
+	if isOffline(err) {
+		return queuePatch(loaded, patch)
+	}
+	return after, err
+}
+
+// sendPatch sends the patch to the calendar, as patchActivity does when online
+func sendPatch(ctx context.Context, loaded *calendar.Event, patch *calendar.Event, srv *calendar.Service) (*calendar.Event, error) {
+	after, err := patchEvent(ctx, loaded.Id, patch, loaded.Etag, srv)
+	if isPreconditionFailed(err) {
+		remote, errGet := getEvent(ctx, loaded.Id, srv)
+		if errGet != nil {
+			return nil, err
+		}
+		return nil, &ConflictError{Loaded: copyEvent(loaded), Remote: remote, Patch: patch}
+	}
+	if err != nil {
+		return nil, err
+	}
+	recordMutation(OperationUpdate, loaded, after)
+	return after, nil
+}
+
+// ResolveConflict applies the change that was in conflict anyway.
+// If overwrite is set, the event gets back the state gogenda loaded with the change applied, dropping
+// what has been done meanwhile. Otherwise the change is merged : only the fields gogenda wanted to change are sent.
+func ResolveConflict(ctx context.Context, conflict *ConflictError, overwrite bool, srv *calendar.Service) (*calendar.Event, error) {
+	after, err := applyResolution(ctx, conflict, overwrite, srv)
+	if err != nil {
+		return nil, err
+	}
+	recordMutation(OperationUpdate, conflict.Remote, after)
+	return after, nil
+}
+
+// applyResolution sends the change that was in conflict, as ResolveConflict does, without recording it in the journal
+func applyResolution(ctx context.Context, conflict *ConflictError, overwrite bool, srv *calendar.Service) (after *calendar.Event, err error) {
+	if overwrite {
+		event := applyPatch(conflict.Loaded, conflict.Patch)
+		event.Etag = ""
+		event.Sequence = conflict.Remote.Sequence
+		after, err = updateEvent(ctx, conflict.Loaded.Id, event, srv)
+	} else {
+		after, err = patchEvent(ctx, conflict.Loaded.Id, conflict.Patch, "", srv)
+	}
+	return after, err
+}
@@ -0,0 +1,193 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package google_agenda_api
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// chunkDays is the number of days of events asked in one request
+const chunkDays = 30
+
+// maxParallelFetches is the maximum number of chunks of events asked at the same time
+const maxParallelFetches = 4
+
+// pageSize is the number of events asked per page. There are as many pages as needed
+const pageSize = 250
+
+// chunkResult holds the events of one chunk of days, or why they could not be fetched
+type chunkResult struct {
+	events []*calendar.Event
+	err    error
+}
+
+// EventIterator goes through all the events between two dates, ordered by start time.
+// The range is fetched by chunks of days, several at a time, so that long histories can be streamed.
+//
+//	it := NewEventIterator(ctx, begin, end, srv)
+//	defer it.Close()
+//	for it.Next() {
+//		event := it.Event()
+//	}
+//	if it.Err() != nil { ... }
+type EventIterator struct {
+	chunks  []chan chunkResult
+	begins  []time.Time
+	stop    chan struct{}
+	index   int
+	pending []*calendar.Event
+	current *calendar.Event
+	err     error
+}
+
+// NewEventIterator starts fetching the events between the dates given in parameters,
+// from the local cache unless it is disabled or the dates are outside of it
+func NewEventIterator(ctx context.Context, begin time.Time, end time.Time, srv *calendar.Service) *EventIterator {
+	it := &EventIterator{stop: make(chan struct{})}
+	if CacheEnabled {
+		it.pending, it.err = cachedEventsBetween(ctx, begin, end, srv)
+		if it.err != errOutsideCache {
+			return it
+		}
+		it.err = nil
+	}
+	for chunkBegin := begin; chunkBegin.Before(end); chunkBegin = chunkBegin.AddDate(0, 0, chunkDays) {
+		it.begins = append(it.begins, chunkBegin)
+		// Buffered, so that the fetches never wait for the iterator
+		it.chunks = append(it.chunks, make(chan chunkResult, 1))
+	}
+
+	go func() {
+		semaphore := make(chan struct{}, maxParallelFetches)
+		for i, chunkBegin := range it.begins {
+			chunkEnd := chunkBegin.AddDate(0, 0, chunkDays)
+			if chunkEnd.After(end) {
+				chunkEnd = end
+			}
+			select {
+			case semaphore <- struct{}{}:
+			case <-it.stop:
+				return
+			}
+			go func(result chan chunkResult, chunkBegin time.Time, chunkEnd time.Time) {
+				defer func() { <-semaphore }()
+				events, err := fetchEvents(ctx, chunkBegin, chunkEnd, srv)
+				if err == nil {
+					// The mutations done offline are shown as if they were sent
+					events = withPendingEvents(events, chunkBegin, chunkEnd)
+					sortByStart(events)
+				}
+				result <- chunkResult{events: events, err: err}
+			}(it.chunks[i], chunkBegin, chunkEnd)
+		}
+	}()
+	return it
+}
+
+// fetchEvents gets all the events between the dates given in parameters, following the pages
+func fetchEvents(ctx context.Context, begin time.Time, end time.Time, srv *calendar.Service) (events []*calendar.Event, err error) {
+	pageToken := ""
+	for {
+		call := srv.Events.List("primary").ShowDeleted(false).SingleEvents(true).
+			TimeMin(begin.Format(time.RFC3339)).TimeMax(end.Format(time.RFC3339)).
+			MaxResults(pageSize).OrderBy("startTime")
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+		page, err := listEvents(ctx, call)
+		if err != nil {
+			return events, err
+		}
+		events = append(events, page.Items...)
+		if page.NextPageToken == "" {
+			return events, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// sortByStart orders the events by start time
+func sortByStart(events []*calendar.Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		start, _ := GetEventStart(events[i], time.Local)
+		otherStart, _ := GetEventStart(events[j], time.Local)
+		return start.Before(otherStart)
+	})
+}
+
+// Next moves to the next event, and returns false when there are no more events or when it failed
+func (it *EventIterator) Next() bool {
+	for len(it.pending) == 0 {
+		if it.err != nil || it.index >= len(it.chunks) {
+			it.current = nil
+			return false
+		}
+		var result chunkResult
+		select {
+		case result = <-it.chunks[it.index]:
+		case <-it.stop:
+			it.current = nil
+			return false
+		}
+		if result.err != nil {
+			it.err = result.err
+			it.Close()
+			continue
+		}
+		for _, event := range result.events {
+			// The events that started before the chunk were already given with the previous one
+			start, _ := GetEventStart(event, time.Local)
+			if it.index == 0 || !start.Before(it.begins[it.index]) {
+				it.pending = append(it.pending, event)
+			}
+		}
+		it.index++
+	}
+	it.current = it.pending[0]
+	it.pending = it.pending[1:]
+	return true
+}
+
+// Event returns the current event
+func (it *EventIterator) Event() *calendar.Event {
+	return it.current
+}
+
+// Err returns why the iteration stopped, nil if all the events were given
+func (it *EventIterator) Err() error {
+	return it.err
+}
+
+// Close stops fetching the chunks that were not asked yet
+func (it *EventIterator) Close() {
+	select {
+	case <-it.stop:
+	default:
+		close(it.stop)
+	}
+}
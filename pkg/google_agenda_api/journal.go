@@ -0,0 +1,252 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package google_agenda_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// Kinds of mutations recorded in the journal
+const (
+	OperationInsert = "insert"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+// Maximum number of mutations kept in the journal
+const journalMaxSize = 512
+
+// JournalEntry is a mutation done on the calendar, with the event as it was before and after it
+// Before is nil for an insert, After is nil for a delete
+type JournalEntry struct {
+	// Date of the mutation
+	Date time.Time `json:"date"`
+	// Operation is one of insert, update or delete
+	Operation string `json:"operation"`
+	// Before is the event before the mutation
+	Before *calendar.Event `json:"before,omitempty"`
+	// After is the event after the mutation
+	After *calendar.Event `json:"after,omitempty"`
+	// Undone is set when the mutation has been reverted with undo
+	Undone bool `json:"undone"`
+}
+
+// Event returns the most relevant state of the event touched by the mutation
+func (entry JournalEntry) Event() *calendar.Event {
+	if entry.After != nil {
+		return entry.After
+	}
+	return entry.Before
+}
+
+// journalPath returns the path of the journal file
+func journalPath() string {
+	return Dir + "/journal.json"
+}
+
+// LoadJournal loads all the mutations recorded in the journal, the oldest first
+func LoadJournal() (journal []JournalEntry, err error) {
+	f, err := os.Open(journalPath())
+	if os.IsNotExist(err) {
+		return journal, nil
+	}
+	if err != nil {
+		return journal, err
+	}
+	defer f.Close()
+	err = json.NewDecoder(f).Decode(&journal)
+	return journal, err
+}
+
+// saveJournal saves the journal, dropping the oldest mutations if there are too much
+func saveJournal(journal []JournalEntry) (err error) {
+	if len(journal) > journalMaxSize {
+		journal = journal[len(journal)-journalMaxSize:]
+	}
+	f, err := os.OpenFile(journalPath(), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(journal)
+}
+
+// journalMutex protects the journal file from the mutations done at the same time, by a batch
+var journalMutex sync.Mutex
+
+// copyEvent returns a deep copy of the event, to keep its state before modifying it
+func copyEvent(event *calendar.Event) *calendar.Event {
+	if event == nil {
+		return nil
+	}
+	b, err := json.Marshal(event)
+	if err != nil {
+		return nil
+	}
+	eventCopy := &calendar.Event{}
+	json.Unmarshal(b, eventCopy)
+	return eventCopy
+}
+
+// recordMutation adds a mutation to the journal
+// The journal is best effort : failing to write it must not fail the mutation itself
+func recordMutation(operation string, before *calendar.Event, after *calendar.Event) {
+	journalMutex.Lock()
+	defer journalMutex.Unlock()
+	journal, err := LoadJournal()
+	if err != nil {
+		// Corrupted journal, start a new one
+		journal = nil
+	}
+	journal = append(journal, JournalEntry{
+		Date:      time.Now(),
+		Operation: operation,
+		Before:    copyEvent(before),
+		After:     copyEvent(after),
+	})
+	saveJournal(journal)
+}
+
+// GetUndoableMutations returns the nb last mutations that have not been undone yet, the most recent first
+func GetUndoableMutations(nb int) (entries []JournalEntry, err error) {
+	journal, err := LoadJournal()
+	if err != nil {
+		return entries, err
+	}
+	for i := len(journal) - 1; i >= 0 && len(entries) < nb; i-- {
+		if !journal[i].Undone {
+			entries = append(entries, journal[i])
+		}
+	}
+	return entries, nil
+}
+
+// cleanEventForInsert removes the fields set by google from an event, so it can be inserted again
+func cleanEventForInsert(event *calendar.Event) *calendar.Event {
+	newEvent := copyEvent(event)
+	newEvent.Id = ""
+	newEvent.ICalUID = ""
+	newEvent.Etag = ""
+	newEvent.HtmlLink = ""
+	newEvent.Created = ""
+	newEvent.Updated = ""
+	newEvent.Sequence = 0
+	newEvent.Status = ""
+	return newEvent
+}
+
+// ConflictResolver asks the user what to do with a conflict : overwrite the changes done meanwhile or merge with them.
+// Returns false if the user aborted
+type ConflictResolver func(conflict *ConflictError) (overwrite bool, ok bool)
+
+// revertPatch returns the patch giving back to an event the fields it had before an update
+func revertPatch(before *calendar.Event) *calendar.Event {
+	return &calendar.Event{
+		Summary:     before.Summary,
+		Description: before.Description,
+		Location:    before.Location,
+		ColorId:     before.ColorId,
+		Start:       before.Start,
+		End:         before.End,
+		// The fields that were empty have to be emptied again
+		ForceSendFields: []string{"Summary", "Description", "Location", "ColorId"},
+	}
+}
+
+// revertMutation restores the calendar as it was before the mutation.
+// An update is only reverted if the event is still as the mutation left it : returns a *ConflictError otherwise.
+// Returns the event as it is now on the calendar, nil if it has been deleted
+func revertMutation(ctx context.Context, entry JournalEntry, srv *calendar.Service) (*calendar.Event, error) {
+	switch entry.Operation {
+	case OperationInsert:
+		return nil, deleteEvent(ctx, entry.After.Id, srv)
+	case OperationUpdate:
+		patch := revertPatch(entry.Before)
+		// The etag of the event as the mutation left it : the one before the mutation never matches anymore
+		event, err := patchEvent(ctx, entry.After.Id, patch, entry.After.Etag, srv)
+		if isPreconditionFailed(err) {
+			remote, errGet := getEvent(ctx, entry.After.Id, srv)
+			if errGet != nil {
+				return nil, err
+			}
+			return nil, &ConflictError{Loaded: copyEvent(entry.After), Remote: remote, Patch: patch}
+		}
+		return event, err
+	case OperationDelete:
+		return insertEvent(ctx, cleanEventForInsert(entry.Before), srv)
+	}
+	return nil, errors.New("unknown operation '" + entry.Operation + "'")
+}
+
+// UndoLastMutations reverts the nb last mutations that have not been undone yet, the most recent first.
+// Deleted events are inserted again, with a new id that replaces the old one in the journal.
+// Events modified on the calendar since are only reverted as the resolver decides, it stops if the user aborts.
+// Returns the mutations that have been undone
+func UndoLastMutations(ctx context.Context, nb int, resolve ConflictResolver, srv *calendar.Service) (undone []JournalEntry, err error) {
+	journal, err := LoadJournal()
+	if err != nil {
+		return undone, err
+	}
+	for i := len(journal) - 1; i >= 0 && len(undone) < nb; i-- {
+		if journal[i].Undone {
+			continue
+		}
+		event, err := revertMutation(ctx, journal[i], srv)
+		if conflict, isConflict := err.(*ConflictError); isConflict {
+			overwrite, ok := resolve(conflict)
+			if !ok {
+				return undone, saveJournal(journal)
+			}
+			event, err = applyResolution(ctx, conflict, overwrite, srv)
+		}
+		if err != nil {
+			saveJournal(journal)
+			return undone, err
+		}
+		journal[i].Undone = true
+		undone = append(undone, journal[i])
+		if event == nil {
+			continue
+		}
+		// The event may have a new id, and has a new etag : older mutations have to refer to it as it is now
+		oldID := journal[i].Event().Id
+		for j := 0; j < i; j++ {
+			if journal[j].Before != nil && journal[j].Before.Id == oldID {
+				journal[j].Before.Id = event.Id
+			}
+			if journal[j].After != nil && journal[j].After.Id == oldID {
+				journal[j].After.Id = event.Id
+				journal[j].After.Etag = event.Etag
+			}
+		}
+	}
+	return undone, saveJournal(journal)
+}
@@ -0,0 +1,249 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package google_agenda_api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// provisionalPrefix starts the ids given to the events inserted offline. Google ids never contain a '-'
+const provisionalPrefix = "local-"
+
+// OutboxEntry is a mutation done while gogenda was offline, waiting to be sent to the calendar
+type OutboxEntry struct {
+	// Date of the mutation
+	Date time.Time `json:"date"`
+	// Operation is insert or update
+	Operation string `json:"operation"`
+	// EventID is the id of the event, a provisional one for the events inserted offline
+	EventID string `json:"eventId"`
+	// Event is the event to insert, or the event as it was loaded for an update
+	Event *calendar.Event `json:"event"`
+	// Patch holds the fields changed by an update
+	Patch *calendar.Event `json:"patch,omitempty"`
+}
+
+// SyncResult is what happened to a mutation of the outbox when it was sent
+type SyncResult struct {
+	Entry OutboxEntry
+	// Event is the event on the calendar after the mutation
+	Event *calendar.Event
+	// Err is why the mutation failed, a *ConflictError if the event changed on the calendar meanwhile
+	Err error
+}
+
+// outboxMutex protects the outbox file from the mutations queued at the same time, by a batch
+var outboxMutex sync.Mutex
+
+// outboxPath returns the path of the outbox file
+func outboxPath() string {
+	return Dir + "/outbox.json"
+}
+
+// IsProvisionalID returns true for the ids of the events inserted offline and not sent yet
+func IsProvisionalID(id string) bool {
+	return strings.HasPrefix(id, provisionalPrefix)
+}
+
+// isOffline returns true when the calendar could not be reached or did not answer in time,
+// as opposed to an error answered by the api
+func isOffline(err error) bool {
+	return IsErrorKind(err, ErrorOffline) || IsErrorKind(err, ErrorTimeout)
+}
+
+// LoadOutbox loads the mutations waiting to be sent, the oldest first
+func LoadOutbox() (outbox []OutboxEntry, err error) {
+	f, err := os.Open(outboxPath())
+	if os.IsNotExist(err) {
+		return outbox, nil
+	}
+	if err != nil {
+		return outbox, err
+	}
+	defer f.Close()
+	err = json.NewDecoder(f).Decode(&outbox)
+	return outbox, err
+}
+
+// saveOutbox saves the mutations waiting to be sent, and removes the file when there are none
+func saveOutbox(outbox []OutboxEntry) (err error) {
+	if len(outbox) == 0 {
+		err = os.Remove(outboxPath())
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	f, err := os.OpenFile(outboxPath(), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(outbox)
+}
+
+// queueInsert keeps an event to insert once online, and returns it with a provisional id
+func queueInsert(event *calendar.Event) (*calendar.Event, error) {
+	outboxMutex.Lock()
+	defer outboxMutex.Unlock()
+	outbox, err := LoadOutbox()
+	if err != nil {
+		return nil, err
+	}
+	queued := copyEvent(event)
+	queued.Id = provisionalPrefix + strconv.FormatInt(time.Now().UnixNano(), 36)
+	outbox = append(outbox, OutboxEntry{Date: time.Now(), Operation: OperationInsert, EventID: queued.Id, Event: queued})
+	return copyEvent(queued), saveOutbox(outbox)
+}
+
+// queuePatch keeps a change to send once online, and returns the event with the change applied.
+// The changes of an event inserted offline are merged in its insert
+func queuePatch(loaded *calendar.Event, patch *calendar.Event) (*calendar.Event, error) {
+	outboxMutex.Lock()
+	defer outboxMutex.Unlock()
+	outbox, err := LoadOutbox()
+	if err != nil {
+		return nil, err
+	}
+	if IsProvisionalID(loaded.Id) {
+		for i := range outbox {
+			if outbox[i].Operation == OperationInsert && outbox[i].EventID == loaded.Id {
+				outbox[i].Event = applyPatch(outbox[i].Event, patch)
+				return copyEvent(outbox[i].Event), saveOutbox(outbox)
+			}
+		}
+		return nil, errors.New("the event '" + loaded.Summary + "' is not waiting to be sent anymore")
+	}
+	outbox = append(outbox, OutboxEntry{Date: time.Now(), Operation: OperationUpdate, EventID: loaded.Id, Event: copyEvent(loaded), Patch: patch})
+	return applyPatch(loaded, patch), saveOutbox(outbox)
+}
+
+// queuedEvent returns the event inserted offline with the provisional id given in parameters, with its changes
+func queuedEvent(id string) (*calendar.Event, error) {
+	outboxMutex.Lock()
+	defer outboxMutex.Unlock()
+	outbox, err := LoadOutbox()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range outbox {
+		if entry.Operation == OperationInsert && entry.EventID == id {
+			return copyEvent(entry.Event), nil
+		}
+	}
+	return nil, errors.New("the event " + id + " is not waiting to be sent anymore, show your plan again")
+}
+
+// dropQueuedEvent forgets an event inserted offline, with its changes
+func dropQueuedEvent(id string) error {
+	outboxMutex.Lock()
+	defer outboxMutex.Unlock()
+	outbox, err := LoadOutbox()
+	if err != nil {
+		return err
+	}
+	var kept []OutboxEntry
+	for _, entry := range outbox {
+		if entry.EventID != id {
+			kept = append(kept, entry)
+		}
+	}
+	return saveOutbox(kept)
+}
+
+// withPendingEvents applies the mutations waiting to be sent to the events given in parameters,
+// and adds the events inserted offline that occur between the dates given
+func withPendingEvents(events []*calendar.Event, begin time.Time, end time.Time) []*calendar.Event {
+	outbox, err := LoadOutbox()
+	if err != nil || len(outbox) == 0 {
+		return events
+	}
+	for _, entry := range outbox {
+		switch entry.Operation {
+		case OperationUpdate:
+			for i, event := range events {
+				if event.Id == entry.EventID {
+					events[i] = applyPatch(event, entry.Patch)
+				}
+			}
+		case OperationInsert:
+			start, errStart := GetEventStart(entry.Event, time.Local)
+			stop, errStop := GetEventEnd(entry.Event, time.Local)
+			if errStart == nil && errStop == nil && start.Before(end) && stop.After(begin) {
+				events = append(events, copyEvent(entry.Event))
+			}
+		}
+	}
+	return events
+}
+
+// CountPendingMutations returns the number of mutations waiting to be sent
+func CountPendingMutations() int {
+	outbox, _ := LoadOutbox()
+	return len(outbox)
+}
+
+// SyncOutbox sends the mutations done offline, in order. The provisional ids of the events inserted offline
+// are replaced by their ids on the calendar. It stops if the calendar cannot be reached, keeping what is left.
+// Returns what happened to each mutation sent : the ones that failed are dropped, conflicts are returned
+// as *ConflictError so that they can be resolved. The ids on the calendar of the events sent are returned
+// by provisional id, for the ones kept elsewhere to be replaced
+func SyncOutbox(ctx context.Context, srv *calendar.Service) (results []SyncResult, ids map[string]string, err error) {
+	ids = make(map[string]string)
+	outbox, err := LoadOutbox()
+	if err != nil {
+		return nil, ids, err
+	}
+	for i, entry := range outbox {
+		result := SyncResult{Entry: entry}
+		switch entry.Operation {
+		case OperationInsert:
+			result.Event, result.Err = insertEvent(ctx, cleanEventForInsert(entry.Event), srv)
+			if result.Err == nil {
+				ids[entry.EventID] = result.Event.Id
+				recordMutation(OperationInsert, nil, result.Event)
+			}
+		case OperationUpdate:
+			loaded := copyEvent(entry.Event)
+			if id, ok := ids[entry.EventID]; ok {
+				loaded.Id = id
+			}
+			result.Event, result.Err = sendPatch(ctx, loaded, entry.Patch, srv)
+		}
+		if isOffline(result.Err) {
+			return results, ids, saveOutbox(outbox[i:])
+		}
+		results = append(results, result)
+	}
+	return results, ids, saveOutbox(nil)
+}
@@ -0,0 +1,144 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package google_agenda_api
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+func TestSyncOutboxReplacesProvisionalIDs(t *testing.T) {
+	begin := time.Date(2020, time.March, 2, 9, 0, 0, 0, time.UTC)
+	existing := timedEvent("existing", begin.Add(3*time.Hour))
+	fake, srv, stop := newFakeCalendar(t, existing)
+	defer stop()
+	ctx := context.Background()
+
+	// Offline, the activity is kept with a provisional id, and can still be changed and copied
+	fake.offline = true
+	activity, err := InsertActivity(ctx, "Writing", "", begin, begin.Add(time.Hour), srv)
+	if err != nil || !IsProvisionalID(activity.Id) {
+		t.Fatalf("inserting offline gave %v, %v, want a provisional id", activity.Id, err)
+	}
+	err = RenameActivityByID(ctx, activity.Id, "Writing the doc", srv)
+	if err != nil {
+		t.Fatalf("renaming offline : unexpected error %v", err)
+	}
+	err = CopyActivityFromID(ctx, activity.Id, begin.AddDate(0, 0, 1), srv)
+	if err != nil {
+		t.Fatalf("copying offline : unexpected error %v", err)
+	}
+	_, err = queuePatch(existing, &calendar.Event{Summary: "Reading"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if CountPendingMutations() != 3 {
+		t.Fatalf("%d changes are waiting, want the insert, the copy and the update", CountPendingMutations())
+	}
+
+	// Back online, the events get their ids on the calendar
+	fake.offline = false
+	results, ids, err := SyncOutbox(ctx, srv)
+	if err != nil || len(results) != 3 {
+		t.Fatalf("SyncOutbox = %v, %v, want the 3 changes sent", results, err)
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			t.Errorf("sending %s %s : unexpected error %v", result.Entry.Operation, result.Entry.EventID, result.Err)
+		}
+	}
+	sent, ok := ids[activity.Id]
+	if !ok || IsProvisionalID(sent) || sent != results[0].Event.Id || len(ids) != 2 {
+		t.Fatalf("the ids returned are %v, want the ones of the 2 events inserted offline", ids)
+	}
+	if results[0].Event.Summary != "Writing the doc" {
+		t.Errorf("the event sent is %q, want the rename done offline", results[0].Event.Summary)
+	}
+	if CountPendingMutations() != 0 {
+		t.Errorf("%d changes are still waiting", CountPendingMutations())
+	}
+
+	// The provisional id is not known anymore, the one on the calendar is
+	err = RenameActivityByID(ctx, activity.Id, "Reviewing the doc", srv)
+	if err == nil {
+		t.Errorf("renaming the provisional id after the sync should fail")
+	}
+	err = RenameActivityByID(ctx, sent, "Reviewing the doc", srv)
+	if err != nil || fake.requests[len(fake.requests)-1] != "PATCH /calendars/primary/events/"+sent {
+		t.Errorf("renaming %s after the sync gave %v with the requests %v", sent, err, fake.requests)
+	}
+}
+
+func TestSyncOutboxKeepsWhatIsLeftOffline(t *testing.T) {
+	begin := time.Date(2020, time.March, 2, 9, 0, 0, 0, time.UTC)
+	fake, srv, stop := newFakeCalendar(t)
+	defer stop()
+	ctx := context.Background()
+
+	fake.offline = true
+	kept, err := InsertActivity(ctx, "Writing", "", begin, begin.Add(time.Hour), srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dropped, err := InsertActivity(ctx, "Reading", "", begin.Add(time.Hour), begin.Add(2*time.Hour), srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Deleting an event inserted offline only forgets it
+	err = DeleteActivityFromID(ctx, dropped.Id, srv)
+	if err != nil || CountPendingMutations() != 1 {
+		t.Fatalf("deleting offline gave %v with %d changes waiting, want only the other insert", err, CountPendingMutations())
+	}
+
+	results, ids, err := SyncOutbox(ctx, srv)
+	if err != nil || len(results) != 0 || len(ids) != 0 || CountPendingMutations() != 1 {
+		t.Errorf("SyncOutbox still offline = %v, %v, %v with %d changes waiting, want the insert kept",
+			results, ids, err, CountPendingMutations())
+	}
+	if _, err = queuedEvent(kept.Id); err != nil {
+		t.Errorf("the event inserted offline is lost : %v", err)
+	}
+}
+
+func TestTimeoutsAreOffline(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: newError("get event", &url.Error{Op: "Get", URL: "https://www.googleapis.com", Err: context.DeadlineExceeded}), want: true},
+		{err: newError("get event", &url.Error{Op: "Get", URL: "https://www.googleapis.com", Err: errors.New("connection refused")}), want: true},
+		{err: newError("get event", context.DeadlineExceeded), want: true},
+		{err: newError("get event", context.Canceled), want: false},
+	}
+	for _, test := range tests {
+		if got := isOffline(test.err); got != test.want {
+			t.Errorf("isOffline(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package google_agenda_api
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/calendar/v3"
+)
+
+// Scopes of an operation done on an occurrence of a recurring event
+const (
+	// ScopeThis is only the given occurrence
+	ScopeThis = "this"
+	// ScopeFollowing is the given occurrence and all the following ones
+	ScopeFollowing = "following"
+	// ScopeAll is the whole series
+	ScopeAll = "all"
+)
+
+// endRecurrenceBefore returns the recurrence rules ending right before the given date.
+// The series of all day events end on the day before, as their end has to be a date too
+func endRecurrenceBefore(recurrence []string, date time.Time, allDay bool) []string {
+	until := "UNTIL=" + date.Add(-time.Second).UTC().Format("20060102T150405Z")
+	if allDay {
+		until = "UNTIL=" + date.AddDate(0, 0, -1).Format("20060102")
+	}
+	return replaceRecurrenceEnd(recurrence, until)
+}
+
+// replaceRecurrenceEnd returns the recurrence rules with their end replaced by the one given, like "COUNT=3"
+func replaceRecurrenceEnd(recurrence []string, end string) []string {
+	var rules []string
+	for _, rule := range recurrence {
+		if !strings.HasPrefix(rule, "RRULE:") {
+			rules = append(rules, rule)
+			continue
+		}
+		// Replace the end of the series, whether it was a date or a number of occurrences
+		var parts []string
+		for _, part := range strings.Split(strings.TrimPrefix(rule, "RRULE:"), ";") {
+			if !strings.HasPrefix(part, "UNTIL=") && !strings.HasPrefix(part, "COUNT=") {
+				parts = append(parts, part)
+			}
+		}
+		rules = append(rules, "RRULE:"+strings.Join(append(parts, end), ";"))
+	}
+	return rules
+}
+
+// recurrenceCount returns the number of occurrences of the series, false if it doesn't end after a number of them
+func recurrenceCount(recurrence []string) (int, bool) {
+	for _, rule := range recurrence {
+		if !strings.HasPrefix(rule, "RRULE:") {
+			continue
+		}
+		for _, part := range strings.Split(strings.TrimPrefix(rule, "RRULE:"), ";") {
+			if strings.HasPrefix(part, "COUNT=") {
+				count, err := strconv.Atoi(strings.TrimPrefix(part, "COUNT="))
+				return count, err == nil
+			}
+		}
+	}
+	return 0, false
+}
+
+// countOccurrencesBefore returns the number of occurrences of the series, cancelled ones included,
+// that were due before the given date. The series has to be finite
+func countOccurrencesBefore(ctx context.Context, series *calendar.Event, date time.Time, srv *calendar.Service) (nb int, err error) {
+	pageToken := ""
+	for {
+		call := srv.Events.Instances("primary", series.Id).ShowDeleted(true).MaxResults(pageSize).PageToken(pageToken)
+		var page *calendar.Events
+		err = retry(ctx, "list occurrences", true, func(ctx context.Context) (err error) {
+			page, err = call.Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, occurrence := range page.Items {
+			start, err := parseEventDateTime(occurrence.OriginalStartTime, date.Location())
+			if err == nil && start.Before(date) {
+				nb++
+			}
+		}
+		if page.NextPageToken == "" {
+			return nb, nil
+		}
+		pageToken = page.NextPageToken
+	}
+}
+
+// originalStart returns the date the occurrence was due at in its series, even if it has been moved since
+func originalStart(occurrence *calendar.Event) (time.Time, error) {
+	if occurrence.OriginalStartTime == nil {
+		return GetEventStart(occurrence, time.Local)
+	}
+	return parseEventDateTime(occurrence.OriginalStartTime, time.Local)
+}
+
+// getOccurrenceAndSeries returns the occurrence of the id given in parameters and the series it belongs to
+func getOccurrenceAndSeries(ctx context.Context, occurrenceID string, srv *calendar.Service) (occurrence *calendar.Event, series *calendar.Event, err error) {
+	occurrence, err = getEvent(ctx, occurrenceID, srv)
+	if err != nil {
+		return nil, nil, err
+	}
+	if occurrence.RecurringEventId == "" {
+		return nil, nil, errors.New("the event '" + occurrence.Summary + "' is not recurring")
+	}
+	series, err = getEvent(ctx, occurrence.RecurringEventId, srv)
+	return occurrence, series, err
+}
+
+// TruncateRecurringActivity : Ends the series of the occurrence given in parameters right before it,
+// so that the occurrence and all the following ones are removed
+// Also give a pointer the the calendar service in order to send the api.
+func TruncateRecurringActivity(ctx context.Context, occurrenceID string, srv *calendar.Service) (err error) {
+	occurrence, series, err := getOccurrenceAndSeries(ctx, occurrenceID, srv)
+	if err != nil {
+		return err
+	}
+	occurrenceStart, err := originalStart(occurrence)
+	if err != nil {
+		return err
+	}
+	_, err = patchActivity(ctx, series, &calendar.Event{Recurrence: endRecurrenceBefore(series.Recurrence, occurrenceStart, IsAllDay(series))}, srv)
+	return err
+}
+
+// SplitRecurringActivity : Splits the series of the occurrence given in parameters in two : the old series ends
+// right before the occurrence, and a new one, with the same recurrence, starts with it.
+// Also give a pointer the the calendar service in order to send the api.
+// It will return, if it succeeds, the id of the new series.
+func SplitRecurringActivity(ctx context.Context, occurrenceID string, srv *calendar.Service) (seriesID string, err error) {
+	occurrence, series, err := getOccurrenceAndSeries(ctx, occurrenceID, srv)
+	if err != nil {
+		return "", err
+	}
+	occurrenceStart, err := originalStart(occurrence)
+	if err != nil {
+		return "", err
+	}
+	seriesStart, _ := GetEventStart(series, time.Local)
+	seriesEnd, _ := GetEventEnd(series, time.Local)
+
+	// The new series, starting with the occurrence
+	newSeries := cleanEventForInsert(series)
+	newSeries.RecurringEventId = ""
+	if IsAllDay(series) {
+		newSeries.Start, newSeries.End = movedAllDayDates(seriesStart, seriesEnd, occurrenceStart)
+	} else {
+		newSeries.Start.DateTime = occurrenceStart.Format(time.RFC3339)
+		newSeries.End.DateTime = occurrenceStart.Add(seriesEnd.Sub(seriesStart)).Format(time.RFC3339)
+	}
+	if count, hasCount := recurrenceCount(series.Recurrence); hasCount {
+		// The new series only has the occurrences the old one had left
+		before, err := countOccurrencesBefore(ctx, series, occurrenceStart, srv)
+		if err != nil {
+			return "", err
+		}
+		if count-before < 1 {
+			return "", errors.New("the series of '" + series.Summary + "' has no occurrence left to split")
+		}
+		newSeries.Recurrence = replaceRecurrenceEnd(series.Recurrence, "COUNT="+strconv.Itoa(count-before))
+	}
+	newSeries, err = insertEvent(ctx, newSeries, srv)
+	if err != nil {
+		return "", err
+	}
+	recordMutation(OperationInsert, nil, newSeries)
+
+	// The old series ends before it
+	_, err = patchActivity(ctx, series, &calendar.Event{Recurrence: endRecurrenceBefore(series.Recurrence, occurrenceStart, IsAllDay(series))}, srv)
+	if err != nil {
+		return "", err
+	}
+	return newSeries.Id, nil
+}
@@ -0,0 +1,238 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package google_agenda_api
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/oauth2"
+	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// Timeout is the maximum duration of one request to the api
+var Timeout = 10 * time.Second
+
+// maxAttempts is the number of times a request is sent before giving up, when google asks to retry later
+const maxAttempts = 5
+
+// firstBackoff is the wait before the first retry, doubled for each new one
+const firstBackoff = 500 * time.Millisecond
+
+// Kinds of errors returned by the api package
+const (
+	ErrorOffline      = "offline"
+	ErrorTimeout      = "timeout"
+	ErrorCanceled     = "canceled"
+	ErrorRateLimited  = "rate limited"
+	ErrorUnavailable  = "unavailable"
+	ErrorNotFound     = "not found"
+	ErrorUnauthorized = "unauthorized"
+	ErrorRejected     = "rejected"
+)
+
+// errorMessages explain each kind of error to the user
+var errorMessages = map[string]string{
+	ErrorOffline:      "google agenda cannot be reached, check your connection",
+	ErrorTimeout:      "google agenda took too long to answer",
+	ErrorCanceled:     "the request has been canceled",
+	ErrorRateLimited:  "too many requests were sent to google agenda, try again in a few minutes",
+	ErrorUnavailable:  "google agenda is not available right now, try again later",
+	ErrorNotFound:     "the event does not exist anymore",
+	ErrorUnauthorized: "gogenda is not allowed to access your calendar anymore, log in again",
+	ErrorRejected:     "google agenda refused the request",
+}
+
+// Error is a request to the api that failed
+type Error struct {
+	// Kind is what went wrong, one of the Error constants
+	Kind string
+	// Operation is what was asked, like "get event"
+	Operation string
+	// Err is the error returned by the api client
+	Err error
+}
+
+func (e *Error) Error() string {
+	return errorMessages[e.Kind] + " (" + e.Operation + " : " + e.Err.Error() + ")"
+}
+
+// Unwrap returns the error returned by the api client
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// IsErrorKind checks if the error is an api error of the kind given in parameters
+func IsErrorKind(err error, kind string) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Kind == kind
+}
+
+// newError gives its kind to an error returned by the api client
+func newError(operation string, err error) error {
+	var apiErr *Error
+	if err == nil || errors.As(err, &apiErr) {
+		return err
+	}
+	kind := ErrorRejected
+	var googleErr *googleapi.Error
+	var urlErr *url.Error
+	var authErr *oauth2.RetrieveError
+	switch {
+	case errors.As(err, &googleErr):
+		kind = googleErrorKind(googleErr)
+	case errors.Is(err, context.DeadlineExceeded):
+		kind = ErrorTimeout
+	case errors.Is(err, context.Canceled):
+		kind = ErrorCanceled
+	case errors.As(err, &authErr):
+		kind = ErrorUnauthorized
+	case errors.As(err, &urlErr):
+		kind = ErrorOffline
+		if urlErr.Timeout() {
+			kind = ErrorTimeout
+		}
+	}
+	return &Error{Kind: kind, Operation: operation, Err: err}
+}
+
+// googleErrorKind returns the kind of an error answered by the api
+func googleErrorKind(err *googleapi.Error) string {
+	switch {
+	case err.Code == http.StatusTooManyRequests:
+		return ErrorRateLimited
+	case err.Code == http.StatusForbidden:
+		for _, item := range err.Errors {
+			if item.Reason == "rateLimitExceeded" || item.Reason == "userRateLimitExceeded" {
+				return ErrorRateLimited
+			}
+		}
+		return ErrorUnauthorized
+	case err.Code == http.StatusUnauthorized:
+		return ErrorUnauthorized
+	case err.Code == http.StatusNotFound || err.Code == http.StatusGone:
+		return ErrorNotFound
+	case err.Code >= 500:
+		return ErrorUnavailable
+	}
+	return ErrorRejected
+}
+
+// retry sends the request until it succeeds, fails with an error not worth retrying, or runs out of attempts.
+// Each attempt has its own timeout, and waits twice as long as the previous one, with some jitter so that
+// several clients don't retry at the same time. Requests that are not idempotent, like inserts, are only
+// retried when google refused them because of the rate limit, as they might have been done otherwise
+func retry(ctx context.Context, operation string, idempotent bool, request func(ctx context.Context) error) (err error) {
+	backoff := firstBackoff
+	for attempt := 1; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, Timeout)
+		err = newError(operation, request(attemptCtx))
+		cancel()
+		retryable := IsErrorKind(err, ErrorRateLimited) ||
+			(idempotent && (IsErrorKind(err, ErrorUnavailable) || IsErrorKind(err, ErrorTimeout)))
+		if err == nil || !retryable || attempt >= maxAttempts || ctx.Err() != nil {
+			return err
+		}
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return newError(operation, ctx.Err())
+		}
+		backoff *= 2
+	}
+}
+
+// getEvent gets an event from its id, from the outbox for the provisional id of an event inserted offline
+func getEvent(ctx context.Context, id string, srv *calendar.Service) (event *calendar.Event, err error) {
+	if IsProvisionalID(id) {
+		// Inserted offline, the calendar does not know it yet
+		return queuedEvent(id)
+	}
+	err = retry(ctx, "get event", true, func(ctx context.Context) (err error) {
+		event, err = srv.Events.Get("primary", id).Context(ctx).Do()
+		return err
+	})
+	return event, err
+}
+
+// insertEvent adds an event to the calendar
+func insertEvent(ctx context.Context, event *calendar.Event, srv *calendar.Service) (inserted *calendar.Event, err error) {
+	err = retry(ctx, "insert event", false, func(ctx context.Context) (err error) {
+		inserted, err = srv.Events.Insert("primary", event).Context(ctx).Do()
+		return err
+	})
+	return inserted, err
+}
+
+// patchEvent sends the fields of the patch, only if the event still has the etag given when it is not empty
+func patchEvent(ctx context.Context, id string, patch *calendar.Event, etag string, srv *calendar.Service) (patched *calendar.Event, err error) {
+	err = retry(ctx, "patch event", true, func(ctx context.Context) (err error) {
+		call := srv.Events.Patch("primary", id, patch).Context(ctx)
+		if etag != "" {
+			call.Header().Set("If-Match", etag)
+		}
+		patched, err = call.Do()
+		return err
+	})
+	return patched, err
+}
+
+// updateEvent replaces an event
+func updateEvent(ctx context.Context, id string, event *calendar.Event, srv *calendar.Service) (updated *calendar.Event, err error) {
+	err = retry(ctx, "update event", true, func(ctx context.Context) (err error) {
+		updated, err = srv.Events.Update("primary", id, event).Context(ctx).Do()
+		return err
+	})
+	return updated, err
+}
+
+// deleteEvent removes an event from the calendar.
+// An event already gone when the delete is retried has been removed by an attempt whose answer got lost
+func deleteEvent(ctx context.Context, id string, srv *calendar.Service) error {
+	attempt := 0
+	return retry(ctx, "delete event", true, func(ctx context.Context) error {
+		attempt++
+		err := srv.Events.Delete("primary", id).Context(ctx).Do()
+		var googleErr *googleapi.Error
+		if attempt > 1 && errors.As(err, &googleErr) && googleErrorKind(googleErr) == ErrorNotFound {
+			return nil
+		}
+		return err
+	})
+}
+
+// listEvents gets a page of events
+func listEvents(ctx context.Context, call *calendar.EventsListCall) (page *calendar.Events, err error) {
+	err = retry(ctx, "list events", true, func(ctx context.Context) (err error) {
+		page, err = call.Context(ctx).Do()
+		return err
+	})
+	return page, err
+}
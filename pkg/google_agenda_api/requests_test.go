@@ -0,0 +1,57 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package google_agenda_api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestDeleteEventRetried(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		wantKind string
+	}{
+		{name: "deleted at once"},
+		{name: "deleted by the retry", statuses: []int{http.StatusServiceUnavailable}},
+		{name: "deleted by the first attempt, whose answer got lost", statuses: []int{http.StatusServiceUnavailable, http.StatusGone}},
+		{name: "not found by the retry", statuses: []int{http.StatusServiceUnavailable, http.StatusNotFound}},
+		{name: "already gone", statuses: []int{http.StatusGone}, wantKind: ErrorNotFound},
+		{name: "not found", statuses: []int{http.StatusNotFound}, wantKind: ErrorNotFound},
+	}
+	for _, test := range tests {
+		fake, srv, stop := newFakeCalendar(t)
+		fake.statuses = test.statuses
+		err := deleteEvent(context.Background(), "event", srv)
+		if test.wantKind == "" && err != nil {
+			t.Errorf("%s : unexpected error %v", test.name, err)
+		}
+		if test.wantKind != "" && !IsErrorKind(err, test.wantKind) {
+			t.Errorf("%s : got the error %v, want a %s one", test.name, err, test.wantKind)
+		}
+		stop()
+	}
+}
@@ -0,0 +1,346 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package google_agenda_api
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/oauth2"
+)
+
+// Storages of the token
+const (
+	// TokenStorageFile keeps the token in clear in token.json, readable only by the user
+	TokenStorageFile = "file"
+	// TokenStoragePassphrase encrypts the token in token.enc with a passphrase asked when it is needed
+	TokenStoragePassphrase = "passphrase"
+	// TokenStorageKeyring keeps the token in the secret service of the system, with secret-tool on linux
+	TokenStorageKeyring = "keyring"
+)
+
+// TokenStorage is where the token is kept, one of the TokenStorage constants
+var TokenStorage = TokenStorageFile
+
+// AskPassphrase asks the user the passphrase of the token. The GOGENDA_PASSPHRASE environment variable is used first
+var AskPassphrase = func(prompt string) (string, error) {
+	return "", errors.New("no passphrase given, set GOGENDA_PASSPHRASE")
+}
+
+// keyIterations is the number of rounds deriving the key from the passphrase, to slow down guessing it
+const keyIterations = 600000
+
+// errNoToken is returned when no token has been saved yet
+var errNoToken = errors.New("no token saved, log in with 'gogenda auth login'")
+
+// tokenStore loads, saves and removes the token of the calendar
+type tokenStore interface {
+	load() (*oauth2.Token, error)
+	save(token *oauth2.Token) error
+	remove() error
+	// String describes where the token is kept
+	String() string
+}
+
+// getTokenStore returns the store of the token, as chosen with TokenStorage
+func getTokenStore() (tokenStore, error) {
+	switch TokenStorage {
+	case TokenStorageFile, "":
+		return fileTokenStore{path: Dir + "/token.json"}, nil
+	case TokenStoragePassphrase:
+		return &encryptedTokenStore{path: Dir + "/token.enc"}, nil
+	case TokenStorageKeyring:
+		return newKeyringTokenStore(Dir)
+	}
+	return nil, errors.New("Wrong token storage '" + TokenStorage + "', should be file, passphrase or keyring")
+}
+
+// fileTokenStore keeps the token in clear in a file
+type fileTokenStore struct {
+	path string
+}
+
+func (store fileTokenStore) load() (*oauth2.Token, error) {
+	tok, err := tokenFromFile(store.path)
+	if os.IsNotExist(err) {
+		return nil, errNoToken
+	}
+	return tok, err
+}
+
+func (store fileTokenStore) save(token *oauth2.Token) error {
+	return saveToken(store.path, token)
+}
+
+func (store fileTokenStore) remove() error {
+	return removeIfExists(store.path)
+}
+
+func (store fileTokenStore) String() string {
+	return store.path
+}
+
+// removeIfExists removes a file, it is fine if it is already gone
+func removeIfExists(path string) error {
+	err := os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// encryptedToken is the content of the file of an encrypted token
+type encryptedToken struct {
+	Salt       []byte `json:"salt"`
+	Iterations int    `json:"iterations"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// encryptedTokenStore keeps the token in a file, encrypted with AES-GCM and a key derived from a passphrase
+type encryptedTokenStore struct {
+	path string
+	// passphrase is asked once, and kept to save the refreshed tokens
+	passphrase string
+}
+
+// getPassphrase returns the passphrase, asking it if it is not known yet. A new one is asked twice
+func (store *encryptedTokenStore) getPassphrase(isNew bool) (string, error) {
+	if store.passphrase != "" {
+		return store.passphrase, nil
+	}
+	if passphrase := os.Getenv("GOGENDA_PASSPHRASE"); passphrase != "" {
+		store.passphrase = passphrase
+		return passphrase, nil
+	}
+	if !isNew {
+		passphrase, err := AskPassphrase("the passphrase of your token")
+		store.passphrase = passphrase
+		return passphrase, err
+	}
+	passphrase, err := AskPassphrase("a passphrase to encrypt your token")
+	if err != nil {
+		return "", err
+	}
+	again, err := AskPassphrase("the passphrase again")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" || passphrase != again {
+		return "", errors.New("the passphrases are empty or do not match")
+	}
+	store.passphrase = passphrase
+	return passphrase, nil
+}
+
+// newCipher returns the cipher of the key derived from the passphrase
+func newCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	// A 32 bytes key, for AES-256
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, iterations, 32, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (store *encryptedTokenStore) load() (*oauth2.Token, error) {
+	b, err := ioutil.ReadFile(store.path)
+	if os.IsNotExist(err) {
+		return nil, errNoToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	var encrypted encryptedToken
+	err = json.Unmarshal(b, &encrypted)
+	if err != nil {
+		return nil, err
+	}
+	passphrase, err := store.getPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newCipher(passphrase, encrypted.Salt, encrypted.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, encrypted.Nonce, encrypted.Data, nil)
+	if err != nil {
+		store.passphrase = ""
+		return nil, errors.New("wrong passphrase, or " + store.path + " has been altered")
+	}
+	tok := &oauth2.Token{}
+	err = json.Unmarshal(plain, tok)
+	return tok, err
+}
+
+func (store *encryptedTokenStore) save(token *oauth2.Token) error {
+	_, err := os.Stat(store.path)
+	passphrase, err := store.getPassphrase(os.IsNotExist(err))
+	if err != nil {
+		return err
+	}
+	plain, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	encrypted := encryptedToken{Salt: make([]byte, 16), Iterations: keyIterations}
+	if _, err = rand.Read(encrypted.Salt); err != nil {
+		return err
+	}
+	aead, err := newCipher(passphrase, encrypted.Salt, encrypted.Iterations)
+	if err != nil {
+		return err
+	}
+	encrypted.Nonce = make([]byte, aead.NonceSize())
+	if _, err = rand.Read(encrypted.Nonce); err != nil {
+		return err
+	}
+	encrypted.Data = aead.Seal(nil, encrypted.Nonce, plain, nil)
+	b, err := json.Marshal(encrypted)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(store.path, b, 0600)
+}
+
+func (store *encryptedTokenStore) remove() error {
+	return removeIfExists(store.path)
+}
+
+func (store *encryptedTokenStore) String() string {
+	return store.path + " (encrypted with a passphrase)"
+}
+
+// keyringTokenStore keeps the token in the secret service of the system, one entry per folder of profile.
+// Only secret-tool is used : it reads the secret from its input, where the other users cannot see it
+type keyringTokenStore struct {
+	account string
+}
+
+// newKeyringTokenStore returns the keyring store of the folder given in parameters,
+// if the system has a secret service gogenda can use
+func newKeyringTokenStore(account string) (tokenStore, error) {
+	if runtime.GOOS != "linux" {
+		return nil, errors.New("No secret service gogenda can use on " + runtime.GOOS + ", use the file or passphrase token storage")
+	}
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return nil, errors.New("No secret service found (secret-tool), use the file or passphrase token storage")
+	}
+	return keyringTokenStore{account: account}, nil
+}
+
+// RemoveKeyringToken removes the token kept in the secret service of the system for the folder given in parameters,
+// if there is one. The tokens of the other storages are files of the folder
+func RemoveKeyringToken(dir string) error {
+	store, err := newKeyringTokenStore(dir)
+	if err != nil {
+		// No secret service, so no token in it
+		return nil
+	}
+	return store.remove()
+}
+
+// commandError is the failure of a command of the secret service, with what it wrote on its error output
+type commandError struct {
+	name   string
+	err    error
+	stderr string
+}
+
+func (e *commandError) Error() string {
+	return fmt.Sprintf("%s failed: %v %s", e.name, e.err, e.stderr)
+}
+
+func (e *commandError) Unwrap() error {
+	return e.err
+}
+
+// run runs the command of the secret service, with the input given, and returns its output
+func (store keyringTokenStore) run(input string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return "", &commandError{name: name, err: err, stderr: strings.TrimSpace(stderr.String())}
+	}
+	return stdout.String(), nil
+}
+
+// isMissingSecret returns true when secret-tool failed only because there is no such secret :
+// it then exits with 1 without any message
+func isMissingSecret(err error) bool {
+	var cmdErr *commandError
+	var exitErr *exec.ExitError
+	return errors.As(err, &cmdErr) && cmdErr.stderr == "" && errors.As(err, &exitErr) && exitErr.ExitCode() == 1
+}
+
+func (store keyringTokenStore) load() (*oauth2.Token, error) {
+	out, err := store.run("", "secret-tool", "lookup", "application", "gogenda", "profile", store.account)
+	if isMissingSecret(err) || (err == nil && strings.TrimSpace(out) == "") {
+		return nil, errNoToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	tok := &oauth2.Token{}
+	err = json.Unmarshal([]byte(out), tok)
+	return tok, err
+}
+
+func (store keyringTokenStore) save(token *oauth2.Token) error {
+	b, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	_, err = store.run(string(b), "secret-tool", "store", "--label=gogenda token", "application", "gogenda", "profile", store.account)
+	return err
+}
+
+func (store keyringTokenStore) remove() error {
+	if _, err := store.load(); err == errNoToken {
+		return nil
+	}
+	_, err := store.run("", "secret-tool", "clear", "application", "gogenda", "profile", store.account)
+	return err
+}
+
+func (store keyringTokenStore) String() string {
+	return "the secret service of the system"
+}
@@ -0,0 +1,100 @@
+/*
+MIT License
+
+Copyright (c) 2020 Julien LE THENO
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+package google_agenda_api
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestKeyringTokenStoreLoad(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the secret service is only used on linux")
+	}
+	tests := []struct {
+		name string
+		// script acts as secret-tool
+		script    string
+		wantToken string
+		wantErr   error
+		// anyErr is set when an error other than wantErr is expected
+		anyErr bool
+	}{
+		{
+			name:      "a token is saved",
+			script:    `echo '{"access_token":"access","token_type":"Bearer"}'`,
+			wantToken: "access",
+		},
+		{
+			name:    "no such secret",
+			script:  "exit 1",
+			wantErr: errNoToken,
+		},
+		{
+			name:    "an empty secret",
+			script:  "exit 0",
+			wantErr: errNoToken,
+		},
+		{
+			name:   "the secret service cannot be reached",
+			script: "echo 'Cannot autolaunch D-Bus without X11 $DISPLAY' >&2; exit 1",
+			anyErr: true,
+		},
+		{
+			name:   "the secret service crashed",
+			script: "exit 2",
+			anyErr: true,
+		},
+	}
+	dir, err := ioutil.TempDir("", "gogenda")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	for _, test := range tests {
+		err := ioutil.WriteFile(filepath.Join(dir, "secret-tool"), []byte("#!/bin/sh\n"+test.script+"\n"), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		store, err := newKeyringTokenStore(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, err := store.load()
+		switch {
+		case test.anyErr && (err == nil || err == errNoToken):
+			t.Errorf("%s : got %v, want the error of the secret service", test.name, err)
+		case !test.anyErr && err != test.wantErr:
+			t.Errorf("%s : got the error %v, want %v", test.name, err, test.wantErr)
+		case test.wantToken != "" && (token == nil || token.AccessToken != test.wantToken):
+			t.Errorf("%s : got the token %v, want %s", test.name, token, test.wantToken)
+		}
+	}
+}